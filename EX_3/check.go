@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// disorder describes the first line of an input that is out of order.
+type disorder struct {
+	name   string
+	lineNo int
+	line   string
+}
+
+func (d *disorder) String() string {
+	return fmt.Sprintf("%s:%d: disorder: %s", d.name, d.lineNo, d.line)
+}
+
+// checkSorted reads the input name and returns the first line that sorts
+// before its predecessor, or nil when the input is in order. With unique set,
+// a line whose key equals that of its predecessor is also out of order.
+func checkSorted(name string, cmp *comparator, unique bool) (*disorder, error) {
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	src := &mergeSource{name: name, r: bufio.NewReader(r)}
+	var prev string
+	for lineNo := 1; ; lineNo++ {
+		ok, err := src.next()
+		if err != nil || !ok {
+			return nil, err
+		}
+		if lineNo > 1 {
+			r := cmp.compare(prev, src.line)
+			if r > 0 || unique && r == 0 {
+				return &disorder{name: name, lineNo: lineNo, line: src.line}, nil
+			}
+		}
+		prev = src.line
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSorted(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		input    string
+		cmp      *comparator
+		unique   bool
+		expected string
+	}{
+		{"a\nb\nb\nc\n", &comparator{}, false, ""},
+		// Only the first line out of order is reported.
+		{"a\nc\nb\nd\na\n", &comparator{}, false, "sort: IN:3: disorder: b"},
+		{"a\nb\nb\nc\n", &comparator{}, true, "sort: IN:3: disorder: b"},
+		// With -u, lines with equal keys are out of order even when they differ.
+		{"x 1\ny 1\n", &comparator{column: 2, kind: numericKey}, true, "sort: IN:2: disorder: y 1"},
+		{"x 1\ny 1\n", &comparator{column: 2, kind: numericKey}, false, ""},
+		{"10\n9\n", &comparator{kind: numericKey, reverse: true}, false, ""},
+		{"", &comparator{}, true, ""},
+	}
+
+	for i, test := range tests {
+		name := filepath.Join(dir, "in"+string(rune('0'+i)))
+		if err := os.WriteFile(name, []byte(test.input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		d, err := checkSorted(name, test.cmp, test.unique)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := ""
+		if d != nil {
+			result = strings.Replace("sort: "+d.String(), name, "IN", 1)
+		}
+		if result != test.expected {
+			t.Errorf("%q (unique %v): expected %q, but got %q", test.input, test.unique, test.expected, result)
+		}
+	}
+
+	if _, err := checkSorted(filepath.Join(dir, "missing"), &comparator{}, false); err == nil {
+		t.Errorf("expected an error for a missing input")
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// comparator orders lines according to the sort flags. Sorting, merging and
+// the -c check all use the same comparator, so they always agree on the
+// order of two lines.
+type comparator struct {
+	column     int
+	kind       keyKind
+	ignoreTail bool
+	reverse    bool
+	salt       []byte      // hash salt of randomKey
+	times      *timeParser // timestamp parser of timeKey
+	json       *jsonPath   // key path in --jsonl mode instead of column
+}
+
+// keyKind selects how two keys are compared.
+type keyKind int
+
+const (
+	lexicalKey keyKind = iota
+	numericKey
+	monthKey
+	versionKey
+	naturalKey
+	randomKey
+	timeKey
+)
+
+var monthNumbers = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4,
+	"may": 5, "jun": 6, "jul": 7, "aug": 8,
+	"sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// key extracts the part of line the comparison is based on.
+func (c *comparator) key(line string) string {
+	var field string
+	if c.json != nil {
+		field = c.json.lookup(line)
+	} else if c.kind == timeKey {
+		// A timestamp may span several fields, the parser decides how much
+		// to use.
+		field = getFields(line, c.column, c.times.words)
+	} else {
+		field = getField(line, c.column)
+	}
+	if c.ignoreTail {
+		field = strings.TrimRightFunc(field, unicode.IsSpace)
+	}
+	return field
+}
+
+// compare returns a negative number when a sorts before b, a positive number
+// when it sorts after b and zero when their keys are equal.
+func (c *comparator) compare(a, b string) int {
+	r := c.compareKeys(c.key(a), c.key(b))
+	if c.reverse {
+		return -r
+	}
+	return r
+}
+
+func (c *comparator) compareKeys(a, b string) int {
+	switch c.kind {
+	case monthKey:
+		return cmp.Compare(monthNumber(a), monthNumber(b))
+	case numericKey:
+		return compareNumeric(a, b)
+	case versionKey:
+		return compareVersions(a, b)
+	case naturalKey:
+		return compareNatural(a, b)
+	case randomKey:
+		return c.compareRandom(a, b)
+	case timeKey:
+		return c.compareTimes(a, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func (c *comparator) less(a, b string) bool {
+	return c.compare(a, b) < 0
+}
+
+// monthNumber returns 1-12 for a month name abbreviation in any case, and 0
+// for anything else so that unknown values sort before January.
+func monthNumber(s string) int {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) > 3 {
+		s = s[:3]
+	}
+	return monthNumbers[s]
+}
+
+// applyModifiers sets the comparison options from the modifier letters of a
+// key specification such as the "nr" in "-k 2nr".
+func (c *comparator) applyModifiers(mods string) error {
+	for _, m := range mods {
+		switch m {
+		case 'b':
+			c.ignoreTail = true
+		case 'r':
+			c.reverse = true
+		case 'n', 'h':
+			c.kind = numericKey
+		case 'M':
+			c.kind = monthKey
+		case 'V':
+			c.kind = versionKey
+		case 'N':
+			c.kind = naturalKey
+		case 'R':
+			c.kind = randomKey
+		case 'T':
+			c.kind = timeKey
+		default:
+			return fmt.Errorf("unknown key modifier %q", m)
+		}
+	}
+	return nil
+}
+
+// parseKey splits a key specification into its column number and modifier
+// letters, e.g. "2V" into 2 and "V".
+func parseKey(spec string) (int, string, error) {
+	i := strings.IndexFunc(spec, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(spec)
+	}
+	if i == 0 {
+		return 0, "", fmt.Errorf("invalid key %q: missing column number", spec)
+	}
+	column, err := strconv.Atoi(spec[:i])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid key %q: %w", spec, err)
+	}
+	return column, spec[i:], nil
+}
+
+func getField(line string, column int) string {
+	return getFields(line, column, 1)
+}
+
+// getFields returns up to n fields starting at column, joined by a space.
+func getFields(line string, column, n int) string {
+	fields := strings.Fields(line)
+	if column > 0 && column <= len(fields) {
+		return strings.Join(fields[column-1:min(column-1+n, len(fields))], " ")
+	}
+	return line
+}
+
+// compareNumeric orders keys numerically. Integers are compared exactly;
+// otherwise both keys are read as floats, so that "3.5" or "1e3" from a JSON
+// record sort by value. Keys that are not numbers count as zero.
+func compareNumeric(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return cmp.Compare(x, y)
+	}
+	return cmp.Compare(extractNumber(a), extractNumber(b))
+}
+
+// extractNumber reads a decimal number such as "-3.5" or "1e3". ParseFloat
+// also accepts "Inf", "NaN" and hex floats, which do not count as numbers here.
+func extractNumber(s string) float64 {
+	digits := strings.TrimLeft(s, "+-")
+	if digits == "" || digits[0] != '.' && (digits[0] < '0' || digits[0] > '9') || strings.ContainsAny(digits, "xX") {
+		return 0
+	}
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return num
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestComparatorCompare(t *testing.T) {
+	tests := []struct {
+		cmp      comparator
+		a, b     string
+		expected int
+	}{
+		{comparator{}, "apple", "banana", -1},
+		{comparator{reverse: true}, "apple", "banana", 1},
+		{comparator{column: 2, kind: numericKey}, "x 10", "y 9", 1},
+		{comparator{column: 2, kind: numericKey, reverse: true}, "x 10", "y 9", -1},
+		{comparator{kind: numericKey}, "2.5", "10", -1},
+		{comparator{kind: numericKey}, "-1.5", "-1", -1},
+		{comparator{kind: numericKey}, "inf", "1", -1},
+		{comparator{kind: numericKey, json: mustJSONPath(".price")}, `{"price":3.5}`, `{"price":3}`, 1},
+		{comparator{kind: numericKey, json: mustJSONPath(".price")}, `{"price":1e3}`, `{"price":999}`, 1},
+		{comparator{kind: monthKey}, "Feb", "jan", 1},
+		{comparator{kind: monthKey, reverse: true}, "Feb", "jan", -1},
+		{comparator{kind: monthKey}, "foo", "JAN", -1},
+		{comparator{ignoreTail: true}, "a  ", "a", 0},
+		{comparator{kind: versionKey}, "1.2.9", "1.2.10", -1},
+		{comparator{kind: versionKey}, "v1.10.0", "v1.9.3", 1},
+		{comparator{kind: versionKey}, "1.0.0-rc.1", "1.0.0", -1},
+		{comparator{kind: versionKey}, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{comparator{kind: versionKey}, "1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{comparator{kind: versionKey}, "1.0.0-beta.11", "1.0.0-beta.2", 1},
+		{comparator{kind: versionKey}, "1.0.0+build.1", "1.0.0", 0},
+		{comparator{kind: naturalKey}, "file2", "file10", -1},
+		{comparator{kind: naturalKey}, "img12b", "img12a", 1},
+		{comparator{kind: naturalKey}, "a", "a1", -1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "2024-05-01T12:00:00+02:00", "2024-05-01T11:00:00Z", -1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "[01/May/2024:10:30:00 +0000]", "2024-05-01 09:00:00", 1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "garbage", "Jan  2 15:04:05", 1},
+		// Byte order would put "AAA" first: only a parsed timestamp sorts before it.
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "Jan  3 10:00:00 x", "AAA", -1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "Feb  1 10:00:00 x", "Jan 31 09:00:00 y", 1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "Mon Jan  2 15:04:05 UTC 2006 a", "AAA", -1},
+		{comparator{kind: timeKey, times: newTimeParser(nil)}, "Mon, 09 Jan 2006 15:04:05 GMT", "Tue, 03 Jan 2006 23:00:00 GMT", 1},
+		{comparator{column: 2, kind: timeKey, times: newTimeParser(nil)}, "b Jan  3 10:00:00 x", "a AAA", -1},
+	}
+
+	for _, test := range tests {
+		result := test.cmp.compare(test.a, test.b)
+		if sign(result) != test.expected {
+			t.Errorf("%+v: expected %d comparing %q and %q, but got %d", test.cmp, test.expected, test.a, test.b, result)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
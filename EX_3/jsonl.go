@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath addresses a value inside a JSON Lines record, e.g. ".user.id" or
+// ".items.0.name", where numeric segments index arrays. While cache is set,
+// lookup remembers the key of every line it sees; sortLines sets it for the
+// duration of a sort.
+type jsonPath struct {
+	segments []string
+	cache    map[string]string // nil when not caching
+}
+
+// parseJSONPath parses a path of the form ".a.b.c". A lone "." or an empty
+// path selects the whole record.
+func parseJSONPath(s string) (*jsonPath, error) {
+	p := &jsonPath{}
+	if s == "" || s == "." {
+		return p, nil
+	}
+	if !strings.HasPrefix(s, ".") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with '.'", s)
+	}
+	p.segments = strings.Split(s[1:], ".")
+	for _, seg := range p.segments {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid JSON path %q: empty segment", s)
+		}
+	}
+	return p, nil
+}
+
+// lookup returns the value at the path in line as a sort key. Strings are
+// used without quotes, numbers as written in the record and objects and
+// arrays as compact JSON. Lines that are not JSON or lack the path give an
+// empty key and so sort together.
+func (p *jsonPath) lookup(line string) string {
+	if key, ok := p.cache[line]; ok {
+		return key
+	}
+	key := p.extract(line)
+	if p.cache != nil {
+		p.cache[line] = key
+	}
+	return key
+}
+
+func (p *jsonPath) extract(line string) string {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+	for _, seg := range p.segments {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[seg]; !ok {
+				return ""
+			}
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return ""
+			}
+			v = node[i]
+		default:
+			return ""
+		}
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// parseJSONKey splits a --jsonl key specification such as ".user.id:n" into
+// its path and modifier letters.
+func parseJSONKey(spec string) (*jsonPath, string, error) {
+	if spec == "0" {
+		spec = ""
+	}
+	path, mods, _ := strings.Cut(spec, ":")
+	p, err := parseJSONPath(path)
+	return p, mods, err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func mustJSONPath(s string) *jsonPath {
+	p, err := parseJSONPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestJSONPathExtract(t *testing.T) {
+	tests := []struct {
+		path     string
+		line     string
+		expected string
+	}{
+		{".user.id", `{"user":{"id":42,"name":"ann"}}`, "42"},
+		{".user.name", `{"user":{"id":42,"name":"ann"}}`, "ann"},
+		{".price", `{"price":3.50}`, "3.50"},
+		{".items.1.sku", `{"items":[{"sku":"a"},{"sku":"b"}]}`, "b"},
+		{".items.2.sku", `{"items":[{"sku":"a"},{"sku":"b"}]}`, ""},
+		{".items.x", `{"items":[1,2]}`, ""},
+		{".user.email", `{"user":{"id":42}}`, ""},
+		{".user.id.deeper", `{"user":{"id":42}}`, ""},
+		{".ok", `{"ok":true}`, "true"},
+		{".tags", `{"tags":["a","<b>"]}`, `["a","<b>"]`},
+		{".missing", `{"missing":null}`, ""},
+		{".", `[1, 2]`, "[1,2]"},
+		{".user.id", `not json`, ""},
+		{".user.id", ``, ""},
+	}
+
+	for _, test := range tests {
+		if result := mustJSONPath(test.path).extract(test.line); result != test.expected {
+			t.Errorf("expected %q for %s in %s, but got %q", test.expected, test.path, test.line, result)
+		}
+	}
+}
+
+func TestParseJSONPathErrors(t *testing.T) {
+	for _, path := range []string{"user", ".a..b", ".a."} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("expected an error for path %q", path)
+		}
+	}
+}
+
+func TestJSONPathCache(t *testing.T) {
+	cmp := &comparator{json: mustJSONPath(".id"), kind: numericKey}
+	lines := []string{`{"id": 3}`, `{"id": 1}`, `{"id": 2}`}
+	sortLines(lines, cmp)
+	if lines[0] != `{"id": 1}` || lines[2] != `{"id": 3}` {
+		t.Errorf("expected the records ordered by id, but got %q", lines)
+	}
+	if cmp.json.cache != nil {
+		t.Errorf("expected the cache to be dropped after the sort, but it holds %d keys", len(cmp.json.cache))
+	}
+
+	// Checking and merging stream their input, and nothing is cached.
+	cmp.compare(`{"id": 1}`, `{"id": 2}`)
+	if cmp.json.cache != nil {
+		t.Errorf("expected no cache outside a sort, but it holds %d keys", len(cmp.json.cache))
+	}
+}
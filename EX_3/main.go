@@ -1,175 +1,273 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
-	"unicode"
 )
 
 func main() {
-	inputFile := flag.String("i", "", "Input file path")
-	outputFile := flag.String("o", "", "Output file path")
-	column := flag.Int("k", 0, "Column number for sorting (1-based index, 0 for entire line)")
+	inputFile := flag.String("i", "", "Input file path (in addition to FILE arguments)")
+	outputFile := flag.String("o", "", "Output file path (default stdout, may be one of the inputs)")
+	key := flag.String("k", "0", "Sort key: column number (1-based, 0 for entire line) followed by modifiers b, h, M, n, N, r, R, T, V;\nwith --jsonl a path and optional modifiers, e.g. .user.id:n")
+	jsonl := flag.Bool("jsonl", false, "Treat input as JSON Lines and take -k as a JSON path")
 	numSort := flag.Bool("n", false, "Sort numerically")
 	reverse := flag.Bool("r", false, "Sort in reverse order")
-	unique := flag.Bool("u", false, "Unique lines only")
+	unique := flag.Bool("u", false, "Output only the first of lines with equal keys")
+	count := flag.Bool("count", false, "Prefix lines with the number of lines sharing the key (implies -u)")
+	dupsOnly := flag.Bool("dups-only", false, "Output only keys occurring more than once (implies -u)")
 	monthSort := flag.Bool("M", false, "Sort by month name")
 	ignoreTail := flag.Bool("b", false, "Ignore trailing spaces")
-	checkSort := flag.Bool("c", false, "Check if sorted")
+	checkSort := flag.Bool("c", false, "Check if sorted, report the first out-of-order line")
+	checkQuiet := flag.Bool("C", false, "Check if sorted, report only through the exit status")
+	mergeSorted := flag.Bool("m", false, "Merge already sorted files, do not sort")
 	humanSort := flag.Bool("h", false, "Sort by human readable sizes")
+	versionSort := flag.Bool("V", false, "Sort by version number (1.2.9 before 1.2.10, pre-releases first)")
+	naturalSort := flag.Bool("natural", false, "Sort digit runs by their numeric value (file2 before file10)")
+	randomSort := flag.Bool("R", false, "Shuffle by a hash of the key, keeping identical keys together")
+	randomSource := flag.String("random-source", "", "Get the -R hash salt from the first bytes of this file")
+	seed := flag.String("seed", "", "Use this string as the -R hash salt for a reproducible shuffle")
+	timeSort := flag.Bool("T", false, "Sort by timestamp (RFC 3339, common log format, syslog, ...)")
+	var timeFormats []string
+	flag.Func("time-format", "Go time layout tried before the built-in ones for -T (repeatable)", func(s string) error {
+		timeFormats = append(timeFormats, s)
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTIONS] [FILE]...\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "With no FILE, or when FILE is -, read standard input.")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if *inputFile == "" || *outputFile == "" {
-		fmt.Println("Input and output file paths must be specified.")
-		flag.Usage()
-		return
+	inputs := flag.Args()
+	if *inputFile != "" {
+		inputs = append([]string{*inputFile}, inputs...)
+	}
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
 	}
 
-	content, err := ioutil.ReadFile(*inputFile)
+	cmp := &comparator{
+		ignoreTail: *ignoreTail,
+		reverse:    *reverse,
+	}
+	switch {
+	case *monthSort:
+		cmp.kind = monthKey
+	case *numSort || *humanSort:
+		cmp.kind = numericKey
+	case *versionSort:
+		cmp.kind = versionKey
+	case *naturalSort:
+		cmp.kind = naturalKey
+	case *randomSort:
+		cmp.kind = randomKey
+	case *timeSort:
+		cmp.kind = timeKey
+	}
+	var (
+		column int
+		path   *jsonPath
+		mods   string
+		err    error
+	)
+	if *jsonl {
+		path, mods, err = parseJSONKey(*key)
+	} else {
+		column, mods, err = parseKey(*key)
+	}
+	if err == nil && mods != "" {
+		// Like in GNU sort, a key with modifiers does not inherit the global ordering options.
+		*cmp = comparator{}
+		err = cmp.applyModifiers(mods)
+	}
 	if err != nil {
-		fmt.Printf("Error reading input file: %v\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	cmp.column = column
+	cmp.json = path
+	if cmp.kind == randomKey {
+		if cmp.salt, err = randomSalt(*seed, *randomSource); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if cmp.kind == timeKey {
+		cmp.times = newTimeParser(timeFormats)
 	}
 
-	lines := strings.Split(string(content), "\n")
-	if len(lines) > 0 && lines[len(lines)-1] == "" {
-		lines = lines[:len(lines)-1] // Remove trailing empty line if any
+	var dd *dedup
+	if *unique || *count || *dupsOnly {
+		dd = &dedup{cmp: cmp, count: *count, dupsOnly: *dupsOnly}
 	}
 
-	if *checkSort {
-		if isSorted(lines, *column, *numSort, *reverse, *monthSort, *ignoreTail, *humanSort) {
-			fmt.Println("The file is already sorted.")
-		} else {
-			fmt.Println("The file is not sorted.")
+	if *checkSort || *checkQuiet {
+		if len(inputs) > 1 {
+			fmt.Fprintf(os.Stderr, "Extra operand %q not allowed with -c\n", inputs[1])
+			os.Exit(2)
+		}
+		d, err := checkSorted(inputs[0], cmp, *unique)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+			os.Exit(2)
+		}
+		if d != nil {
+			if !*checkQuiet {
+				fmt.Fprintf(os.Stderr, "sort: %v\n", d)
+			}
+			os.Exit(1)
 		}
 		return
 	}
 
-	sortedLines := sortLines(lines, *column, *numSort, *reverse, *unique, *monthSort, *ignoreTail, *humanSort)
-	err = ioutil.WriteFile(*outputFile, []byte(strings.Join(sortedLines, "\n")), 0644)
-	if err != nil {
-		fmt.Printf("Error writing output file: %v\n", err)
-	}
-}
-
-func sortLines(lines []string, column int, numSort, reverse, unique, monthSort, ignoreTail, humanSort bool) []string {
-	if monthSort {
-		monthMap := map[string]time.Month{
-			"jan": time.January, "feb": time.February, "mar": time.March,
-			"apr": time.April, "may": time.May, "jun": time.June,
-			"jul": time.July, "aug": time.August, "sep": time.September,
-			"oct": time.October, "nov": time.November, "dec": time.December,
+	if *mergeSorted {
+		if err := mergeFiles(inputs, *outputFile, cmp, dd); err != nil {
+			fmt.Fprintf(os.Stderr, "Error merging input: %v\n", err)
+			os.Exit(2)
 		}
-		sort.SliceStable(lines, func(i, j int) bool {
-			return monthMap[getField(lines[i], column)] < monthMap[getField(lines[j], column)]
-		})
-	} else if numSort || humanSort {
-		sort.SliceStable(lines, func(i, j int) bool {
-			numI := extractNumber(getField(lines[i], column))
-			numJ := extractNumber(getField(lines[j], column))
-			if reverse {
-				return numI > numJ
-			}
-			return numI < numJ
-		})
-	} else {
-		sort.SliceStable(lines, func(i, j int) bool {
-			fieldI := getField(lines[i], column)
-			fieldJ := getField(lines[j], column)
-			if ignoreTail {
-				fieldI = strings.TrimRightFunc(fieldI, unicode.IsSpace)
-				fieldJ = strings.TrimRightFunc(fieldJ, unicode.IsSpace)
-			}
-			if reverse {
-				return fieldI > fieldJ
-			}
-			return fieldI < fieldJ
-		})
+		return
 	}
 
-	if unique {
-		lines = uniqueLines(lines)
+	lines, err := readInputs(inputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(2)
 	}
 
-	return lines
+	sortLines(lines, cmp)
+	if err := writeOutput(*outputFile, lines, dd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(2)
+	}
 }
 
-func getField(line string, column int) string {
-	fields := strings.Fields(line)
-	if column > 0 && column <= len(fields) {
-		return fields[column-1]
+// readInputs reads and concatenates the lines of all named inputs, where "-"
+// stands for standard input. Every input is read completely before any output
+// is opened, so the output file may safely be one of the inputs.
+func readInputs(names []string) ([]string, error) {
+	var lines []string
+	for _, name := range names {
+		r, err := openInput(name)
+		if err != nil {
+			return nil, err
+		}
+		lines, err = appendLines(lines, r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
 	}
-	return line
+	return lines, nil
 }
 
-func extractNumber(s string) int {
-	num, err := strconv.Atoi(s)
-	if err != nil {
-		return 0
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
 	}
-	return num
+	return os.Open(name)
 }
 
-func uniqueLines(lines []string) []string {
-	seen := make(map[string]bool)
-	result := []string{}
-	for _, line := range lines {
-		if !seen[line] {
-			seen[line] = true
-			result = append(result, line)
+// appendLines appends every line of r to lines without the trailing newline.
+// A final line without a newline is kept, an empty trailing line is not.
+func appendLines(lines []string, r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
 		}
 	}
-	return result
 }
 
-func isSorted(lines []string, column int, numSort, reverse, monthSort, ignoreTail, humanSort bool) bool {
-	for i := 1; i < len(lines); i++ {
-		if !isOrdered(lines[i-1], lines[i], column, numSort, reverse, monthSort, ignoreTail, humanSort) {
-			return false
-		}
+// writeOutput writes lines to path, or to stdout when path is empty. A file is
+// written to a temporary sibling and renamed into place once complete.
+func writeOutput(path string, lines []string, dd *dedup) error {
+	out, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	w := &lineWriter{w: bufio.NewWriter(out), dedup: dd}
+	for _, line := range lines {
+		w.writeLine(line)
 	}
-	return true
+	if err := w.flush(); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Close()
 }
 
-func isOrdered(a, b string, column int, numSort, reverse, monthSort, ignoreTail, humanSort bool) bool {
-	fieldA := getField(a, column)
-	fieldB := getField(b, column)
+// outputFile is the destination of the sorted lines. For a named file the data
+// goes to a temporary file first so that a failed run never truncates the
+// target, which may also be one of the inputs.
+type outputFile struct {
+	*os.File
+	path string
+}
 
-	if ignoreTail {
-		fieldA = strings.TrimRightFunc(fieldA, unicode.IsSpace)
-		fieldB = strings.TrimRightFunc(fieldB, unicode.IsSpace)
+func createOutput(path string) (*outputFile, error) {
+	if path == "" {
+		return &outputFile{File: os.Stdout}, nil
 	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	return &outputFile{File: tmp, path: path}, nil
+}
 
-	if monthSort {
-		monthMap := map[string]int{
-			"jan": 1, "feb": 2, "mar": 3, "apr": 4,
-			"may": 5, "jun": 6, "jul": 7, "aug": 8,
-			"sep": 9, "oct": 10, "nov": 11, "dec": 12,
-		}
-		monthA := monthMap[strings.ToLower(fieldA)]
-		monthB := monthMap[strings.ToLower(fieldB)]
-		if reverse {
-			return monthA > monthB
-		}
-		return monthA < monthB
+// Close finishes the output and moves a temporary file over the target.
+func (o *outputFile) Close() error {
+	if o.path == "" {
+		return nil
+	}
+	if err := o.File.Close(); err != nil {
+		os.Remove(o.File.Name())
+		return err
+	}
+	if info, err := os.Stat(o.path); err == nil {
+		os.Chmod(o.File.Name(), info.Mode().Perm())
+	} else {
+		os.Chmod(o.File.Name(), 0644)
 	}
+	return os.Rename(o.File.Name(), o.path)
+}
 
-	if numSort || humanSort {
-		numA := extractNumber(fieldA)
-		numB := extractNumber(fieldB)
-		if reverse {
-			return numA > numB
-		}
-		return numA < numB
+// Abort discards a partially written temporary file.
+func (o *outputFile) Abort() {
+	if o.path == "" {
+		return
 	}
+	o.File.Close()
+	os.Remove(o.File.Name())
+}
 
-	if reverse {
-		return fieldA > fieldB
+// sortLines sorts lines in memory. Parsed keys are cached for the duration
+// of the sort, as each line is compared many times; the cache holds a key per
+// distinct line, no more than the lines themselves.
+func sortLines(lines []string, cmp *comparator) {
+	if cmp.times != nil {
+		cmp.times.cache = make(map[string]parsedTime)
+		defer func() { cmp.times.cache = nil }()
+	}
+	if cmp.json != nil {
+		cmp.json.cache = make(map[string]string)
+		defer func() { cmp.json.cache = nil }()
 	}
-	return fieldA < fieldB
+	sort.SliceStable(lines, func(i, j int) bool {
+		return cmp.less(lines[i], lines[j])
+	})
 }
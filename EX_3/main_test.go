@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppendLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"a\nb\n", []string{"a", "b"}},
+		{"a\nb", []string{"a", "b"}},
+		{"a\n\nb\n", []string{"a", "", "b"}},
+		{"\n", []string{""}},
+	}
+
+	for _, test := range tests {
+		result, err := appendLines(nil, strings.NewReader(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("expected %q for %q, but got %q", test.expected, test.input, result)
+		}
+	}
+}
+
+func TestReadInputs(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	stdin := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(first, []byte("b\na"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("d\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stdin, []byte("s1\ns2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := os.Stdin
+	defer func() { os.Stdin = saved }()
+	f, err := os.Open(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	os.Stdin = f
+
+	// The last line of the first file has no newline and still stays a line
+	// of its own.
+	lines, err := readInputs([]string{first, "-", second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"b", "a", "s1", "s2", "d", "c"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, but got %q", expected, lines)
+	}
+
+	if _, err := readInputs([]string{first, filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("expected an error for a missing input")
+	}
+}
+
+func TestSortIntoInput(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data")
+	if err := os.WriteFile(name, []byte("c\na\nb\na\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dedup    *dedup
+		expected string
+	}{
+		{nil, "a\na\nb\nc\n"},
+		{&dedup{cmp: &comparator{}}, "a\nb\nc\n"},
+	}
+
+	for _, test := range tests {
+		lines, err := readInputs([]string{name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sortLines(lines, &comparator{})
+		if err := writeOutput(name, lines, test.dedup); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("%+v: expected %q, but got %q", test.dedup, test.expected, data)
+		}
+	}
+
+	// The file keeps its mode and no temporary file is left behind.
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, but got %v", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the output in %s, but got %d entries", dir, len(entries))
+	}
+}
+
+func TestSortLinesTimeCache(t *testing.T) {
+	cmp := &comparator{kind: timeKey, times: newTimeParser(nil)}
+	lines := []string{"2024-05-03T00:00:00Z", "2024-05-01T00:00:00Z", "2024-05-02T00:00:00Z"}
+	sortLines(lines, cmp)
+	expected := []string{"2024-05-01T00:00:00Z", "2024-05-02T00:00:00Z", "2024-05-03T00:00:00Z"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, but got %q", expected, lines)
+	}
+	if cmp.times.cache != nil {
+		t.Errorf("expected the cache to be dropped after the sort, but it holds %d keys", len(cmp.times.cache))
+	}
+
+	// Outside a sort, such as when checking or merging a stream, nothing is
+	// cached.
+	cmp.compare("2024-05-01T00:00:00Z", "2024-05-02T00:00:00Z")
+	if cmp.times.cache != nil {
+		t.Errorf("expected no cache outside a sort, but it holds %d keys", len(cmp.times.cache))
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mergeSource is one already sorted input taking part in a merge.
+type mergeSource struct {
+	name  string
+	index int
+	r     *bufio.Reader
+	c     io.Closer
+	line  string
+}
+
+// next advances the source to its following line and reports whether there
+// was one.
+func (s *mergeSource) next() (bool, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("%s: %w", s.name, err)
+	}
+	if len(line) == 0 {
+		return false, nil
+	}
+	s.line = strings.TrimSuffix(line, "\n")
+	return true, nil
+}
+
+// mergeHeap orders the current lines of the sources. Equal lines are taken
+// from the earlier input first, which keeps the merge stable.
+type mergeHeap struct {
+	sources []*mergeSource
+	cmp     *comparator
+}
+
+func (h *mergeHeap) Len() int { return len(h.sources) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.sources[i], h.sources[j]
+	if r := h.cmp.compare(a.line, b.line); r != 0 {
+		return r < 0
+	}
+	return a.index < b.index
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+
+func (h *mergeHeap) Push(x any) { h.sources = append(h.sources, x.(*mergeSource)) }
+
+func (h *mergeHeap) Pop() any {
+	old := h.sources
+	s := old[len(old)-1]
+	h.sources = old[:len(old)-1]
+	return s
+}
+
+// mergeFiles merges the already sorted inputs into path (stdout when empty)
+// without sorting them again. Only the current line of every input is held in
+// memory.
+func mergeFiles(names []string, path string, cmp *comparator, dd *dedup) error {
+	h := &mergeHeap{cmp: cmp}
+	defer func() {
+		for _, s := range h.sources {
+			s.c.Close()
+		}
+	}()
+
+	for i, name := range names {
+		r, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		s := &mergeSource{name: name, index: i, r: bufio.NewReader(r), c: r}
+		ok, err := s.next()
+		if err != nil || !ok {
+			r.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		h.sources = append(h.sources, s)
+	}
+	heap.Init(h)
+
+	out, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	w := &lineWriter{w: bufio.NewWriter(out), dedup: dd}
+	for h.Len() > 0 {
+		s := h.sources[0]
+		w.writeLine(s.line)
+		ok, err := s.next()
+		if err != nil {
+			out.Abort()
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+			s.c.Close()
+		}
+	}
+	if err := w.flush(); err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeFilesStable(t *testing.T) {
+	dir := t.TempDir()
+	inputs := [][]string{
+		{"a1 1", "a2 2", "a3 3"},
+		{"b1 1", "b3 3", "b4 4"},
+		{},
+		{"c0 0", "c2 2", "c3 3"},
+	}
+	var names []string
+	for i, lines := range inputs {
+		name := filepath.Join(dir, "in"+string(rune('0'+i)))
+		content := strings.Join(lines, "\n")
+		if len(lines) > 0 {
+			content += "\n"
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	cmp := &comparator{column: 2, kind: numericKey}
+
+	tests := []struct {
+		dedup    *dedup
+		expected []string
+	}{
+		// Lines with equal keys keep the order of their inputs.
+		{nil, []string{"c0 0", "a1 1", "b1 1", "a2 2", "c2 2", "a3 3", "b3 3", "c3 3", "b4 4"}},
+		{&dedup{cmp: cmp}, []string{"c0 0", "a1 1", "a2 2", "a3 3", "b4 4"}},
+	}
+
+	for _, test := range tests {
+		out := filepath.Join(dir, "out")
+		if err := mergeFiles(names, out, cmp, test.dedup); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%+v: expected %q, but got %q", test.dedup, test.expected, result)
+		}
+	}
+}
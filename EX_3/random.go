@@ -0,0 +1,71 @@
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+)
+
+// randomSaltSize is the number of bytes taken from a random source.
+const randomSaltSize = 16
+
+// compareRandom orders keys by a salted hash, which shuffles the lines while
+// keeping identical keys next to each other. Keys with colliding hashes fall
+// back to a byte-wise comparison so that the order stays total.
+func (c *comparator) compareRandom(a, b string) int {
+	if r := cmp.Compare(c.hash(a), c.hash(b)); r != 0 {
+		return r
+	}
+	return strings.Compare(a, b)
+}
+
+func (c *comparator) hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write(c.salt)
+	io.WriteString(h, key)
+	return mix64(h.Sum64())
+}
+
+// mix64 is the MurmurHash3 finalizer. FNV alone changes little in the high
+// bits when only the last byte of a key differs, which would leave keys like
+// "line1", "line2", ... in sequence.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// randomSalt returns the salt for -R. A seed string or the first bytes of
+// the random source file make the shuffle reproducible; without either the
+// salt comes from the system random generator.
+func randomSalt(seed, source string) ([]byte, error) {
+	if seed != "" && source != "" {
+		return nil, errors.New("--seed and --random-source are mutually exclusive")
+	}
+	if seed != "" {
+		return []byte(seed), nil
+	}
+
+	salt := make([]byte, randomSaltSize)
+	if source == "" {
+		_, err := rand.Read(salt)
+		return salt, err
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := io.ReadFull(f, salt); err != nil {
+		return nil, fmt.Errorf("%s: not enough random bytes: %w", source, err)
+	}
+	return salt, nil
+}
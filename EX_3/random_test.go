@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// shuffle sorts a copy of lines with -R and the given seed.
+func shuffle(lines []string, seed string) []string {
+	cmp := &comparator{kind: randomKey, salt: []byte(seed)}
+	result := append([]string(nil), lines...)
+	sort.SliceStable(result, func(i, j int) bool { return cmp.less(result[i], result[j]) })
+	return result
+}
+
+func TestRandomSort(t *testing.T) {
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, "line"+strconv.Itoa(i%50))
+	}
+
+	first := shuffle(lines, "seed")
+	if again := shuffle(lines, "seed"); !reflect.DeepEqual(first, again) {
+		t.Errorf("expected the same order for the same seed")
+	}
+	if other := shuffle(lines, "other"); reflect.DeepEqual(first, other) {
+		t.Errorf("expected a different order for a different seed")
+	}
+
+	sorted := append([]string(nil), first...)
+	sort.Strings(sorted)
+	expected := append([]string(nil), lines...)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("expected a permutation of the input, but got %q", first)
+	}
+
+	// Identical keys end up next to each other, and the lines are shuffled
+	// rather than left in input order.
+	ascending := 0
+	for i := 0; i < len(first); i += 2 {
+		if first[i] != first[i+1] {
+			t.Fatalf("expected equal keys to be adjacent, but got %q", first)
+		}
+		if i > 0 && first[i-2] < first[i] {
+			ascending++
+		}
+	}
+	if ascending > 40 {
+		t.Errorf("expected a shuffled order, but got %q", first)
+	}
+}
+
+func TestRandomSalt(t *testing.T) {
+	if _, err := randomSalt("a", "b"); err == nil {
+		t.Errorf("expected --seed and --random-source to be mutually exclusive")
+	}
+	if salt, err := randomSalt("abc", ""); err != nil || string(salt) != "abc" {
+		t.Errorf("expected the seed as salt, but got %q, %v", salt, err)
+	}
+	a, _ := randomSalt("", "")
+	b, _ := randomSalt("", "")
+	if len(a) != randomSaltSize || reflect.DeepEqual(a, b) {
+		t.Errorf("expected two different random salts, but got %x and %x", a, b)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// timeLayouts are the timestamp formats tried for time keys, after any
+// layouts given with --time-format.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"02/Jan/2006:15:04:05 -0700", // common log format
+	"02/Jan/2006:15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	time.Stamp, // syslog
+}
+
+// timeParser parses timestamp keys. While cache is set it remembers the
+// results, since in an in-memory sort every key takes part in many
+// comparisons; merging and checking stream their input and leave it unset.
+type timeParser struct {
+	layouts []string
+	words   int                   // the most space-separated words in a layout
+	cache   map[string]parsedTime // nil when not caching
+}
+
+type parsedTime struct {
+	t  time.Time
+	ok bool
+}
+
+func newTimeParser(custom []string) *timeParser {
+	p := &timeParser{
+		layouts: append(append([]string{}, custom...), timeLayouts...),
+	}
+	for _, layout := range p.layouts {
+		p.words = max(p.words, len(strings.Fields(layout)))
+	}
+	return p
+}
+
+// parse reads a timestamp from the start of key. The key holds the sort field
+// and as many fields after it as the longest layout has words, so timestamps
+// with spaces such as "2024-05-01 10:00:00", "[01/May/2024:10:00:00 +0200]"
+// or "Jan  2 15:04:05" are read as a whole. The longest prefix that parses
+// wins.
+func (p *timeParser) parse(key string) (time.Time, bool) {
+	if pt, ok := p.cache[key]; ok {
+		return pt.t, pt.ok
+	}
+	var pt parsedTime
+	words := strings.Fields(key)
+	for n := min(p.words, len(words)); n > 0 && !pt.ok; n-- {
+		s := strings.Trim(strings.Join(words[:n], " "), `[]"'`)
+		for _, layout := range p.layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				pt = parsedTime{t: t, ok: true}
+				break
+			}
+		}
+	}
+	if p.cache != nil {
+		p.cache[key] = pt
+	}
+	return pt.t, pt.ok
+}
+
+// compareTimes orders keys chronologically, so timestamps in different zones
+// compare by the instant they denote. Keys without a recognizable timestamp
+// sort after all others, byte-wise among themselves.
+func (c *comparator) compareTimes(a, b string) int {
+	ta, okA := c.times.parse(a)
+	tb, okB := c.times.parse(b)
+	switch {
+	case okA && okB:
+		return ta.Compare(tb)
+	case okA:
+		return -1
+	case okB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// dedup describes how runs of adjacent lines with equal sort keys are
+// collapsed on output. The first line of every run is the one kept.
+type dedup struct {
+	cmp      *comparator
+	count    bool
+	dupsOnly bool
+}
+
+// lineWriter writes sorted lines, collapsing runs of equal keys when dedup is
+// set. The input has to be ordered by the same comparator, as it is after
+// sorting or merging.
+type lineWriter struct {
+	w     *bufio.Writer
+	dedup *dedup
+	first string
+	n     int
+}
+
+func (lw *lineWriter) writeLine(line string) {
+	if lw.dedup == nil {
+		lw.write(line)
+		return
+	}
+	if lw.n > 0 && lw.dedup.cmp.compare(lw.first, line) == 0 {
+		lw.n++
+		return
+	}
+	lw.emitRun()
+	lw.first, lw.n = line, 1
+}
+
+// flush writes out the pending run and flushes the underlying writer.
+func (lw *lineWriter) flush() error {
+	lw.emitRun()
+	return lw.w.Flush()
+}
+
+func (lw *lineWriter) emitRun() {
+	if lw.n == 0 || lw.dedup.dupsOnly && lw.n < 2 {
+		lw.n = 0
+		return
+	}
+	if lw.dedup.count {
+		fmt.Fprintf(lw.w, "%7d ", lw.n)
+	}
+	lw.write(lw.first)
+	lw.n = 0
+}
+
+func (lw *lineWriter) write(line string) {
+	lw.w.WriteString(line)
+	lw.w.WriteByte('\n')
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineWriterDedup(t *testing.T) {
+	// Sorted by the second field, the first field tells equal keys apart.
+	lines := []string{"b 1", "a 1", "c 2", "e 3", "d 3", "f 3"}
+	cmp := &comparator{column: 2, kind: numericKey}
+
+	tests := []struct {
+		dedup    *dedup
+		expected []string
+	}{
+		{nil, lines},
+		{&dedup{cmp: cmp}, []string{"b 1", "c 2", "e 3"}},
+		{&dedup{cmp: cmp, count: true}, []string{"      2 b 1", "      1 c 2", "      3 e 3"}},
+		{&dedup{cmp: cmp, dupsOnly: true}, []string{"b 1", "e 3"}},
+		{&dedup{cmp: cmp, dupsOnly: true, count: true}, []string{"      2 b 1", "      3 e 3"}},
+		{&dedup{cmp: &comparator{}}, lines},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		lw := &lineWriter{w: bufio.NewWriter(&out), dedup: test.dedup}
+		for _, line := range lines {
+			lw.writeLine(line)
+		}
+		if err := lw.flush(); err != nil {
+			t.Fatal(err)
+		}
+		result := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%+v: expected %q, but got %q", test.dedup, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"cmp"
+	"regexp"
+	"strings"
+)
+
+// semverPattern matches version strings such as "1.2.10", "v2.0.0-rc.1" or
+// "1.4.0+build.5".
+var semverPattern = regexp.MustCompile(`^[vV]?(\d+(?:\.\d+)*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// compareVersions orders version numbers component by component, so 1.2.9
+// sorts before 1.2.10. Following semantic versioning, a pre-release such as
+// 1.0.0-rc.1 sorts before the release 1.0.0 and build metadata is ignored.
+// Strings that are not version numbers are compared naturally.
+func compareVersions(a, b string) int {
+	ma := semverPattern.FindStringSubmatch(a)
+	mb := semverPattern.FindStringSubmatch(b)
+	if ma == nil || mb == nil {
+		if ma != nil {
+			return -1
+		}
+		if mb != nil {
+			return 1
+		}
+		return compareNatural(a, b)
+	}
+
+	if r := compareDotted(ma[1], mb[1], compareNumbers); r != 0 {
+		return r
+	}
+	switch {
+	case ma[2] == "" && mb[2] == "":
+		return 0
+	case ma[2] == "":
+		return 1
+	case mb[2] == "":
+		return -1
+	}
+	return compareDotted(ma[2], mb[2], comparePrerelease)
+}
+
+// compareDotted compares dot-separated identifiers pairwise. When one list is
+// a prefix of the other, the shorter list sorts first.
+func compareDotted(a, b string, compare func(a, b string) int) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if r := compare(pa[i], pb[i]); r != 0 {
+			return r
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
+
+// comparePrerelease compares two pre-release identifiers: numeric ones
+// numerically and before alphanumeric ones, which compare lexically.
+func comparePrerelease(a, b string) int {
+	da, db := isDigits(a), isDigits(b)
+	switch {
+	case da && db:
+		return compareNumbers(a, b)
+	case da:
+		return -1
+	case db:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareNatural compares strings chunk by chunk, ordering runs of digits by
+// their numeric value and everything else byte-wise, so "file2" sorts before
+// "file10".
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		ca, ra := nextChunk(a)
+		cb, rb := nextChunk(b)
+		var r int
+		if isDigits(ca) && isDigits(cb) {
+			r = compareNumbers(ca, cb)
+			if r == 0 {
+				// "01" and "1" are numerically equal, keep a stable order between them.
+				r = cmp.Compare(len(ca), len(cb))
+			}
+		} else {
+			r = strings.Compare(ca, cb)
+		}
+		if r != 0 {
+			return r
+		}
+		a, b = ra, rb
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// nextChunk splits off the leading run of digits or non-digits of s.
+func nextChunk(s string) (string, string) {
+	digit := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumbers compares two digit strings of any length by numeric value.
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if r := cmp.Compare(len(a), len(b)); r != 0 {
+		return r
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,73 @@
+package anagram
+
+import (
+	"sort"
+)
+
+// Group — множество анаграмм. Key — слово группы, встретившееся в словаре
+// первым, Words — все слова группы по возрастанию, включая Key.
+type Group struct {
+	Key   string   `json:"key"`
+	Words []string `json:"words"`
+}
+
+// FindAnagramSets находит все множества анаграмм в словаре. Ключ множества —
+// первое встретившееся в словаре слово из него.
+func FindAnagramSets(words []string) map[string][]string {
+	result := make(map[string][]string)
+	for _, g := range FindAnagramGroups(words) {
+		result[g.Key] = g.Words
+	}
+	return result
+}
+
+// FindAnagramGroups находит множества анаграмм с DefaultNormalizer.
+func FindAnagramGroups(words []string) []Group {
+	return FindAnagramGroupsWith(words, DefaultNormalizer)
+}
+
+// FindAnagramGroupsWith находит множества анаграмм из двух и более слов,
+// сравнивая слова по правилам n. Повторы слов и слова без букв ("123" при
+// LettersOnly) отбрасываются, группы идут в
+// порядке первого появления их ключа в словаре, поэтому результат не
+// зависит от обхода map.
+func FindAnagramGroupsWith(words []string, n *Normalizer) []Group {
+	var groups []*Group
+	bySignature := make(map[string]*Group)
+	seen := make(map[string]bool)
+
+	for _, word := range words {
+		word = n.Word(word)
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		key := n.signature(word)
+		if key == "" {
+			continue
+		}
+		g, ok := bySignature[key]
+		if !ok {
+			g = &Group{Key: word}
+			bySignature[key] = g
+			groups = append(groups, g)
+		}
+		g.Words = append(g.Words, word)
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		if len(g.Words) > 1 {
+			sort.Strings(g.Words)
+			result = append(result, *g)
+		}
+	}
+	return result
+}
+
+// sortString сортирует символы в строке в алфавитном порядке.
+func sortString(s string) string {
+	runes := []rune(s)
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return string(runes)
+}
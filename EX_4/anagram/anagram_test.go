@@ -0,0 +1,46 @@
+package anagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindAnagramGroups(t *testing.T) {
+	words := []string{"тяпка", "листок", "пятак", "Пятка", "слово", "столик", "пятак", "слиток"}
+	expected := []Group{
+		{Key: "тяпка", Words: []string{"пятак", "пятка", "тяпка"}},
+		{Key: "листок", Words: []string{"листок", "слиток", "столик"}},
+	}
+
+	if groups := FindAnagramGroups(words); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v, but got %v", expected, groups)
+	}
+
+	sets := FindAnagramSets(words)
+	if len(sets) != 2 || !reflect.DeepEqual(sets["тяпка"], expected[0].Words) {
+		t.Errorf("expected sets keyed by first occurrence, but got %v", sets)
+	}
+}
+
+func TestFindAnagramGroupsSkipsWordsWithoutLetters(t *testing.T) {
+	ru, _ := NormalizerFor("ru")
+	words := []string{"123", "456", "--", "", "кот", "ток"}
+	expected := []Group{{Key: "кот", Words: []string{"кот", "ток"}}}
+
+	if groups := FindAnagramGroupsWith(words, ru); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v, but got %v", expected, groups)
+	}
+	for _, strategy := range []Strategy{SortedRunes, PrimeProduct} {
+		groups := GroupAnagrams(words, GroupOptions{Normalizer: ru, Strategy: strategy, Workers: 2})
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("strategy %d: expected groups %v, but got %v", strategy, expected, groups)
+		}
+	}
+}
+
+func TestFindAnagramGroupsDecomposed(t *testing.T) {
+	nfd := &Normalizer{Form: NFD}
+	if groups := FindAnagramGroupsWith([]string{"pâte", "pêta"}, nfd); len(groups) != 0 {
+		t.Errorf("expected no groups for accents on different letters, but got %v", groups)
+	}
+}
@@ -0,0 +1,88 @@
+package anagram
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DictFormat задаёт формат файла словаря.
+type DictFormat int
+
+const (
+	// AutoFormat определяет формат по расширению файла: .dic — hunspell,
+	// остальное — по одному слову в строке.
+	AutoFormat DictFormat = iota
+	// PlainFormat — по одному слову в строке.
+	PlainFormat
+	// HunspellFormat — словарь hunspell: в первой строке количество слов,
+	// далее слова с необязательными флагами после '/' и морфологическими
+	// полями после пробела или табуляции.
+	HunspellFormat
+)
+
+// ReadDictionary читает слова из r как есть: регистр приводит Normalizer по
+// правилам языка. Пустые строки и комментарии, начинающиеся с '#',
+// пропускаются.
+func ReadDictionary(r io.Reader, format DictFormat) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if format == HunspellFormat {
+			if first {
+				first = false
+				// Первая строка hunspell-словаря — приблизительное число слов.
+				if _, err := strconv.Atoi(line); err == nil {
+					continue
+				}
+			}
+			line = hunspellWord(line)
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// hunspellWord выделяет слово из строки hunspell-словаря вида
+// "слово/ФЛАГИ\tpo:noun". Экранированный "\/" остаётся частью слова.
+func hunspellWord(line string) string {
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		line = line[:i]
+	}
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '/':
+			line = line[:i]
+		}
+	}
+	return strings.ReplaceAll(line, `\/`, "/")
+}
+
+// LoadDictionary читает слова из файла path. При AutoFormat формат
+// определяется по расширению.
+func LoadDictionary(path string, format DictFormat) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == AutoFormat {
+		format = PlainFormat
+		if strings.EqualFold(filepath.Ext(path), ".dic") {
+			format = HunspellFormat
+		}
+	}
+	return ReadDictionary(f, format)
+}
@@ -0,0 +1,74 @@
+package anagram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDictionaryKeepsCase(t *testing.T) {
+	words, err := ReadDictionary(strings.NewReader("# комментарий\nKIZ\n\nIrmak\n"), PlainFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"KIZ", "Irmak"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Fatalf("expected words %v, but got %v", expected, words)
+	}
+
+	// Турецкое I в нижнем регистре — ı, а не i: слово из словаря должно
+	// совпасть с таким же запросом.
+	tr, _ := NormalizerFor("tr")
+	index := NewAnagramIndexWith(tr)
+	for _, w := range words {
+		index.Add(w)
+	}
+	if found := index.Lookup("KIZ"); !reflect.DeepEqual(found, []string{"kız"}) {
+		t.Errorf("expected [kız] for KIZ, but got %v", found)
+	}
+}
+
+func TestHunspellWord(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"слово", "слово"},
+		{"слово/AB", "слово"},
+		{"слово/AB\tpo:noun", "слово"},
+		{"слово po:noun st:слово", "слово"},
+		{"слово\tpo:noun", "слово"},
+		{`1\/2/N`, "1/2"},
+		{`a\/b\/c`, "a/b/c"},
+		{"/AB", ""},
+	}
+
+	for _, test := range tests {
+		if result := hunspellWord(test.line); result != test.expected {
+			t.Errorf("expected %q for %q, but got %q", test.expected, test.line, result)
+		}
+	}
+}
+
+func TestReadHunspellDictionary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Первая строка с числом слов пропускается.
+		{"3\nпятак/AB\nтяпка\tpo:noun\n1\\/2/N\n", []string{"пятак", "тяпка", "1/2"}},
+		// Без числа в первой строке она считается словом.
+		{"пятак/AB\n3\n", []string{"пятак", "3"}},
+		{"2\n\n# комментарий\nкот/X\n", []string{"кот"}},
+	}
+
+	for _, test := range tests {
+		words, err := ReadDictionary(strings.NewReader(test.input), HunspellFormat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(words, test.expected) {
+			t.Errorf("expected words %q for %q, but got %q", test.expected, test.input, words)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package anagram
+
+import (
+	"math/bits"
+	"runtime"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Strategy — способ вычисления сигнатуры слова в GroupAnagrams.
+type Strategy int
+
+const (
+	// SortedRunes — сигнатура из отсортированных букв, как в sortString.
+	SortedRunes Strategy = iota
+	// PrimeProduct — произведение простых чисел, сопоставленных буквам.
+	// По основной теореме арифметики произведение однозначно задаёт набор
+	// букв, а переполнение uint64 проверяется, поэтому коллизий нет. Слова с
+	// буквами вне таблицы или слишком длинные получают сигнатуру SortedRunes.
+	PrimeProduct
+)
+
+// primeLetters — буквы латиницы и кириллицы в порядке убывания частоты,
+// чередуясь: частым буквам достаются маленькие простые числа и
+// произведение реже переполняется.
+const primeLetters = "eоtеaаoиiнnтsсhрrвdлlкcмuдmпwуfяgыyьpгbзvбkчjйxхqжzшюцщэфъё"
+
+// letterPrimes сопоставляет буквам простые числа. Массив вместо map: поиск
+// выполняется для каждой буквы каждого слова.
+var letterPrimes = func() (primes [0x460]uint64) {
+	p := uint64(2)
+	for _, r := range primeLetters {
+		primes[r] = p
+		for p++; !isPrime(p); p++ {
+		}
+	}
+	return primes
+}()
+
+func isPrime(n uint64) bool {
+	for d := uint64(2); d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return n > 1
+}
+
+// signatureKey — сигнатура слова: ненулевое произведение простых чисел либо
+// отсортированные буквы, если произведение посчитать нельзя. Нулевое
+// значение — сигнатура слова без букв.
+type signatureKey struct {
+	product uint64
+	sorted  string
+}
+
+func primeSignature(letters string) (uint64, bool) {
+	product := uint64(1)
+	for _, r := range letters {
+		if r >= rune(len(letterPrimes)) || letterPrimes[r] == 0 {
+			return 0, false
+		}
+		p := letterPrimes[r]
+		hi, lo := bits.Mul64(product, p)
+		if hi != 0 {
+			return 0, false
+		}
+		product = lo
+	}
+	return product, true
+}
+
+// fastSortString — sortString без выделения памяти под руны для коротких
+// слов: сортировка вставками в буфере на стеке.
+func fastSortString(s string) string {
+	var buf [32]rune
+	if utf8.RuneCountInString(s) > len(buf) {
+		return sortString(s)
+	}
+	runes := buf[:0]
+	for _, r := range s {
+		runes = append(runes, r)
+	}
+	for i := 1; i < len(runes); i++ {
+		for j := i; j > 0 && runes[j] < runes[j-1]; j-- {
+			runes[j], runes[j-1] = runes[j-1], runes[j]
+		}
+	}
+	return string(runes)
+}
+
+// GroupOptions настраивает GroupAnagrams.
+type GroupOptions struct {
+	Normalizer *Normalizer // nil — DefaultNormalizer
+	Strategy   Strategy
+	Workers    int // 0 — runtime.GOMAXPROCS(0)
+}
+
+// GroupAnagrams возвращает то же, что FindAnagramGroupsWith, но рассчитан на
+// словари из миллионов слов. Работа идёт в три этапа:
+//
+//  1. слова делятся на части, и в каждой параллельно считаются нормальная
+//     форма, сигнатура и номер шарда по хешу сигнатуры;
+//  2. каждый шард раскладывает свои слова по группам в порядке словаря,
+//     независимо от других шардов;
+//  3. группы всех шардов сливаются в порядке первого появления ключа.
+func GroupAnagrams(words []string, opts GroupOptions) []Group {
+	n := opts.Normalizer
+	if n == nil {
+		n = DefaultNormalizer
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, 256, max(1, len(words)))
+
+	normalized := make([]string, len(words))
+	keys := make([]signatureKey, len(words))
+	hashes := make([]uint64, len(words))
+	shards := make([]uint8, len(words))
+	ids := make([]int32, len(words))
+	chunk := (len(words) + workers - 1) / workers
+	parallel(workers, func(w int) {
+		for i := w * chunk; i < min((w+1)*chunk, len(words)); i++ {
+			word := n.Word(words[i])
+			key := n.signatureKey(word, opts.Strategy)
+			if key == (signatureKey{}) {
+				// Слово без букв не входит ни в одну группу: groupShard
+				// пропускает пустые слова.
+				continue
+			}
+			normalized[i] = word
+			keys[i] = key
+			hashes[i] = keys[i].hash()
+			shards[i] = uint8(hashes[i] % uint64(workers))
+		}
+	})
+
+	shardGroups := make([][]indexedGroup, workers)
+	parallel(workers, func(w int) {
+		shardGroups[w] = groupShard(normalized, keys, hashes, shards, ids, uint8(w), len(words)/workers)
+	})
+
+	var all []indexedGroup
+	for _, groups := range shardGroups {
+		all = append(all, groups...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].first < all[j].first })
+	result := make([]Group, len(all))
+	for i, g := range all {
+		result[i] = g.Group
+	}
+	return result
+}
+
+// indexedGroup — группа вместе с позицией её ключа в словаре.
+type indexedGroup struct {
+	Group
+	first int
+}
+
+// groupShard раскладывает по группам слова шарда shard и возвращает группы
+// из двух и более слов.
+//
+// Группы ищутся по 64-битному хешу сигнатуры, а не по самой сигнатуре: так
+// быстрее, но разные сигнатуры могут получить один хеш. Поэтому группы с
+// одинаковым хешем связаны в цепочку и сигнатура сверяется с сигнатурой
+// первого слова группы. Первый проход только считает размеры групп, чтобы
+// не выделять память под миллионы групп из одного слова. Повторы
+// отбрасываются проверкой внутри группы: группы анаграмм малы, и это
+// дешевле отдельного множества всех слов.
+func groupShard(words []string, keys []signatureKey, hashes []uint64, shards []uint8, ids []int32, shard uint8, sizeHint int) []indexedGroup {
+	byHash := make(map[uint64]int32, sizeHint)
+	var firsts []int
+	var sizes, next []int32
+	for i, word := range words {
+		if shards[i] != shard || word == "" {
+			continue
+		}
+		id, ok := byHash[hashes[i]]
+		for ok && keys[firsts[id]] != keys[i] {
+			id = next[id]
+			ok = id >= 0
+		}
+		if !ok {
+			id = int32(len(firsts))
+			chain, collision := byHash[hashes[i]]
+			if !collision {
+				chain = -1
+			}
+			byHash[hashes[i]] = id
+			firsts = append(firsts, i)
+			sizes = append(sizes, 0)
+			next = append(next, chain)
+		}
+		ids[i] = id
+		sizes[id]++
+	}
+
+	members := make([][]string, len(firsts))
+	for i, word := range words {
+		if shards[i] != shard || word == "" {
+			continue
+		}
+		id := ids[i]
+		if sizes[id] > 1 && !contains(members[id], word) {
+			members[id] = append(members[id], word)
+		}
+	}
+
+	var result []indexedGroup
+	for id, group := range members {
+		if len(group) > 1 {
+			sort.Strings(group)
+			result = append(result, indexedGroup{Group: Group{Key: words[firsts[id]], Words: group}, first: firsts[id]})
+		}
+	}
+	return result
+}
+
+// parallel вызывает f(0), ..., f(workers-1) в отдельных горутинах и ждёт их
+// завершения.
+func parallel(workers int, f func(w int)) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			f(w)
+		}(w)
+	}
+	wg.Wait()
+}
+
+// hash — хеш сигнатуры для распределения по шардам и поиска группы.
+func (k signatureKey) hash() uint64 {
+	if k.product != 0 {
+		return mix64(k.product)
+	}
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(k.sorted); i++ {
+		h = (h ^ uint64(k.sorted[i])) * 1099511628211
+	}
+	return mix64(h)
+}
+
+// mix64 перемешивает биты хеша, чтобы шарды заполнялись равномерно.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func (n *Normalizer) signatureKey(word string, strategy Strategy) signatureKey {
+	letters := n.letters(word, n.LettersOnly)
+	if letters == "" {
+		return signatureKey{}
+	}
+	if strategy == PrimeProduct {
+		if p, ok := primeSignature(letters); ok {
+			return signatureKey{product: p}
+		}
+	}
+	return signatureKey{sorted: fastSortString(letters)}
+}
+
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
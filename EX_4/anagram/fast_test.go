@@ -0,0 +1,87 @@
+package anagram
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// randomWords генерирует словарь из n слов длиной от 3 до maxLen букв. Чем
+// меньше алфавит и короче слова, тем больше в словаре анаграмм и повторов.
+func randomWords(n int, alphabet string, maxLen int) []string {
+	rng := rand.New(rand.NewSource(1))
+	letters := []rune(alphabet)
+	words := make([]string, n)
+	for i := range words {
+		word := make([]rune, 3+rng.Intn(maxLen-2))
+		for j := range word {
+			word[j] = letters[rng.Intn(len(letters))]
+		}
+		words[i] = string(word)
+	}
+	return words
+}
+
+func TestGroupAnagrams(t *testing.T) {
+	words := append(randomWords(20000, "абвгдеклмнопрст", 8), "Пятак", "пятка", "тяпка", "averylongwordthatoverflowsprimes", "primesoverflowsthatwordlongavery")
+	expected := FindAnagramGroups(words)
+
+	for _, strategy := range []Strategy{SortedRunes, PrimeProduct} {
+		for _, workers := range []int{1, 3} {
+			groups := GroupAnagrams(words, GroupOptions{Strategy: strategy, Workers: workers})
+			if !reflect.DeepEqual(groups, expected) {
+				t.Errorf("strategy %d, %d workers: groups differ from FindAnagramGroups", strategy, workers)
+			}
+		}
+	}
+}
+
+// benchmarkWords — словарь, похожий на настоящий: анаграмм в нём немного.
+func benchmarkWords() []string {
+	return randomWords(1000000, "абвгдеёжзийклмнопрстуфхцчшщъыьэюя", 12)
+}
+
+func BenchmarkFindAnagramGroups(b *testing.B) {
+	words := benchmarkWords()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindAnagramGroups(words)
+	}
+}
+
+func BenchmarkGroupAnagramsSorted(b *testing.B) {
+	benchmarkGroupAnagrams(b, GroupOptions{Strategy: SortedRunes})
+}
+
+func BenchmarkGroupAnagramsPrime(b *testing.B) {
+	benchmarkGroupAnagrams(b, GroupOptions{Strategy: PrimeProduct})
+}
+
+func benchmarkGroupAnagrams(b *testing.B, opts GroupOptions) {
+	words := benchmarkWords()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GroupAnagrams(words, opts)
+	}
+}
+
+func TestGroupShardHashCollision(t *testing.T) {
+	words := []string{"ab", "cd", "ba", "dc", "ab"}
+	keys := make([]signatureKey, len(words))
+	for i, w := range words {
+		keys[i] = DefaultNormalizer.signatureKey(w, SortedRunes)
+	}
+	// Все сигнатуры получают один хеш, группы должны различаться по самим сигнатурам.
+	hashes := make([]uint64, len(words))
+	shards := make([]uint8, len(words))
+	ids := make([]int32, len(words))
+
+	groups := groupShard(words, keys, hashes, shards, ids, 0, 0)
+	expected := []indexedGroup{
+		{Group: Group{Key: "ab", Words: []string{"ab", "ba"}}, first: 0},
+		{Group: Group{Key: "cd", Words: []string{"cd", "dc"}}, first: 1},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v, but got %v", expected, groups)
+	}
+}
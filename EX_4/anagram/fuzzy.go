@@ -0,0 +1,117 @@
+package anagram
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// FuzzyMatch — слово, почти являющееся анаграммой искомого.
+type FuzzyMatch struct {
+	Word     string  `json:"word"`
+	Distance int     `json:"distance"` // сколько букв нужно добавить, убрать или заменить
+	Score    float64 `json:"score"`    // 1 — точная анаграмма, 0 — ни одной общей буквы
+}
+
+// LookupFuzzy возвращает слова индекса, которые становятся анаграммами word
+// после не более чем k правок набора букв: добавления, удаления или замены
+// одной буквы. Само слово в результат не входит. Результаты упорядочены по
+// расстоянию, затем по убыванию оценки и по алфавиту.
+//
+// При k = 1 соседние сигнатуры перебираются по алфавиту индекса и ищутся в
+// нём напрямую, при больших k сигнатуры индекса просматриваются целиком.
+func (x *AnagramIndex) LookupFuzzy(word string, k int) []FuzzyMatch {
+	self := x.norm.Word(word)
+	key := x.norm.signature(self)
+
+	x.mu.RLock()
+	var candidates map[string]int
+	if k == 1 {
+		candidates = x.neighbours(key)
+	} else {
+		candidates = make(map[string]int)
+		for sig := range x.groups {
+			if d := signatureDistance(key, sig); d <= k {
+				candidates[sig] = d
+			}
+		}
+	}
+	var result []FuzzyMatch
+	for sig, d := range candidates {
+		score := 1 - float64(d)/float64(max(utf8.RuneCountInString(key), utf8.RuneCountInString(sig), 1))
+		for _, w := range x.groups[sig] {
+			if w != self {
+				result = append(result, FuzzyMatch{Word: w, Distance: d, Score: score})
+			}
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Word < b.Word
+	})
+	return result
+}
+
+// neighbours возвращает сигнатуры индекса на расстоянии не больше 1 от key.
+// Вызывается под блокировкой на чтение.
+func (x *AnagramIndex) neighbours(key string) map[string]int {
+	result := make(map[string]int)
+	try := func(sig string, d int) {
+		if _, ok := x.groups[sig]; ok {
+			if old, seen := result[sig]; !seen || d < old {
+				result[sig] = d
+			}
+		}
+	}
+
+	try(key, 0)
+	runes := []rune(key)
+	for i, r := range runes {
+		if i > 0 && r == runes[i-1] {
+			continue
+		}
+		rest := string(runes[:i]) + string(runes[i+1:])
+		try(rest, 1)
+		for a := range x.runes {
+			if a != r {
+				try(sortString(rest+string(a)), 1)
+			}
+		}
+	}
+	for a := range x.runes {
+		try(sortString(key+string(a)), 1)
+	}
+	return result
+}
+
+// signatureDistance — число правок (добавить, убрать или заменить букву),
+// превращающих набор букв a в набор b. Замена закрывает одну лишнюю и одну
+// недостающую букву, поэтому расстояние равно большему из их количеств.
+func signatureDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	extra, missing := 0, 0
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		switch {
+		case ra[i] == rb[j]:
+			i++
+			j++
+		case ra[i] < rb[j]:
+			extra++
+			i++
+		default:
+			missing++
+			j++
+		}
+	}
+	extra += len(ra) - i
+	missing += len(rb) - j
+	return max(extra, missing)
+}
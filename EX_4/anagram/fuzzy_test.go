@@ -0,0 +1,59 @@
+package anagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupFuzzy(t *testing.T) {
+	index := NewAnagramIndex()
+	for _, w := range []string{"пятак", "тяпка", "пятки", "пятаки", "пята", "слово", "кот"} {
+		index.Add(w)
+	}
+
+	tests := []struct {
+		word     string
+		k        int
+		expected []string
+	}{
+		{"пятак", 0, []string{"тяпка"}},
+		{"пятак", 1, []string{"тяпка", "пятаки", "пята", "пятки"}},
+		{"пятак", 2, []string{"тяпка", "пятаки", "пята", "пятки"}},
+		{"ток", 1, []string{"кот"}},
+		{"слова", 1, []string{"слово"}},
+		{"абв", 1, nil},
+	}
+	for _, test := range tests {
+		var words []string
+		for _, m := range index.LookupFuzzy(test.word, test.k) {
+			words = append(words, m.Word)
+		}
+		if !reflect.DeepEqual(words, test.expected) {
+			t.Errorf("expected %v for %s within %d, but got %v", test.expected, test.word, test.k, words)
+		}
+	}
+
+	matches := index.LookupFuzzy("пятак", 1)
+	if matches[0].Distance != 0 || matches[0].Score != 1 || matches[1].Distance != 1 || matches[1].Score <= matches[3].Score {
+		t.Errorf("unexpected distances or scores %+v", matches)
+	}
+}
+
+func TestSignatureDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"абв", "абв", 0},
+		{"абв", "абвг", 1},
+		{"абв", "аб", 1},
+		{"абв", "абг", 1},
+		{"абв", "где", 3},
+		{"", "аб", 2},
+	}
+	for _, test := range tests {
+		if d := signatureDistance(test.a, test.b); d != test.expected {
+			t.Errorf("expected distance %d between %s and %s, but got %d", test.expected, test.a, test.b, d)
+		}
+	}
+}
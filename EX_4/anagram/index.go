@@ -0,0 +1,145 @@
+package anagram
+
+import (
+	"sort"
+	"sync"
+)
+
+// AnagramIndex хранит слова, сгруппированные по буквенной сигнатуре, и
+// позволяет добавлять, удалять и искать анаграммы за O(1) без пересчёта
+// всего словаря. Безопасен для конкурентного использования.
+type AnagramIndex struct {
+	mu     sync.RWMutex
+	norm   *Normalizer
+	groups map[string][]string // сигнатура -> слова в порядке добавления
+	runes  map[rune]int        // буква -> число сигнатур с ней, алфавит для LookupFuzzy
+	size   int
+}
+
+// NewAnagramIndex создаёт пустой индекс с DefaultNormalizer.
+func NewAnagramIndex() *AnagramIndex {
+	return NewAnagramIndexWith(DefaultNormalizer)
+}
+
+// NewAnagramIndexWith создаёт пустой индекс, сравнивающий слова по правилам n.
+func NewAnagramIndexWith(n *Normalizer) *AnagramIndex {
+	return &AnagramIndex{norm: n, groups: make(map[string][]string), runes: make(map[rune]int)}
+}
+
+// Clone возвращает независимую копию индекса.
+func (x *AnagramIndex) Clone() *AnagramIndex {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	c := &AnagramIndex{
+		norm:   x.norm,
+		groups: make(map[string][]string, len(x.groups)),
+		runes:  make(map[rune]int, len(x.runes)),
+		size:   x.size,
+	}
+	for key, group := range x.groups {
+		c.groups[key] = append([]string(nil), group...)
+	}
+	for r, n := range x.runes {
+		c.runes[r] = n
+	}
+	return c
+}
+
+// Add добавляет слово в индекс. Возвращает false, если слово уже есть или
+// в нём нет букв (пустая строка, "123" при LettersOnly).
+func (x *AnagramIndex) Add(word string) bool {
+	word = x.norm.Word(word)
+	key := x.norm.signature(word)
+	if key == "" {
+		return false
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, w := range x.groups[key] {
+		if w == word {
+			return false
+		}
+	}
+	if len(x.groups[key]) == 0 {
+		x.countRunes(key, 1)
+	}
+	x.groups[key] = append(x.groups[key], word)
+	x.size++
+	return true
+}
+
+// Remove удаляет слово из индекса. Возвращает false, если слова не было.
+func (x *AnagramIndex) Remove(word string) bool {
+	word = x.norm.Word(word)
+	key := x.norm.signature(word)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	group := x.groups[key]
+	for i, w := range group {
+		if w == word {
+			group = append(group[:i:i], group[i+1:]...)
+			if len(group) == 0 {
+				delete(x.groups, key)
+				x.countRunes(key, -1)
+			} else {
+				x.groups[key] = group
+			}
+			x.size--
+			return true
+		}
+	}
+	return false
+}
+
+// countRunes учитывает различные буквы сигнатуры key в алфавите индекса.
+func (x *AnagramIndex) countRunes(key string, delta int) {
+	var prev rune = -1
+	for _, r := range key {
+		if r == prev {
+			continue
+		}
+		prev = r
+		if x.runes[r] += delta; x.runes[r] == 0 {
+			delete(x.runes, r)
+		}
+	}
+}
+
+// Lookup возвращает все слова индекса, составленные из тех же букв, что и
+// word, в порядке добавления. Само слово входит в результат, если оно есть
+// в индексе.
+func (x *AnagramIndex) Lookup(word string) []string {
+	key := x.norm.Key(word)
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return append([]string(nil), x.groups[key]...)
+}
+
+// Groups возвращает группы из двух и более анаграмм. Слова в группе и сами
+// группы отсортированы, поэтому результат не зависит от порядка добавления.
+func (x *AnagramIndex) Groups() [][]string {
+	x.mu.RLock()
+	result := make([][]string, 0, len(x.groups))
+	for _, group := range x.groups {
+		if len(group) > 1 {
+			result = append(result, append([]string(nil), group...))
+		}
+	}
+	x.mu.RUnlock()
+
+	for _, group := range result {
+		sort.Strings(group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+// Len возвращает количество слов в индексе.
+func (x *AnagramIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.size
+}
@@ -0,0 +1,81 @@
+package anagram
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAnagramIndex(t *testing.T) {
+	index := NewAnagramIndex()
+	for _, w := range []string{"пятак", "Пятка", "тяпка", "листок", "слиток", "слово", "пятак"} {
+		index.Add(w)
+	}
+
+	if index.Add("") {
+		t.Errorf("expected an empty word to be rejected")
+	}
+	ru, _ := NormalizerFor("ru")
+	if ruIndex := NewAnagramIndexWith(ru); ruIndex.Add("123") || ruIndex.Add("--") || ruIndex.Len() != 0 {
+		t.Errorf("expected words without letters to be rejected")
+	}
+	if index.Lookup("") != nil {
+		t.Errorf("expected no words for an empty query, but got %v", index.Lookup(""))
+	}
+
+	if index.Len() != 6 {
+		t.Errorf("expected 6 words, but got %d", index.Len())
+	}
+
+	tests := []struct {
+		word     string
+		expected []string
+	}{
+		{"катяп", []string{"пятак", "пятка", "тяпка"}},
+		{"СТОЛИК", []string{"листок", "слиток"}},
+		{"слово", []string{"слово"}},
+		{"нет", nil},
+	}
+	for _, test := range tests {
+		result := index.Lookup(test.word)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("expected %v for word %s, but got %v", test.expected, test.word, result)
+		}
+	}
+
+	expected := [][]string{{"листок", "слиток"}, {"пятак", "пятка", "тяпка"}}
+	if groups := index.Groups(); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v, but got %v", expected, groups)
+	}
+
+	if !index.Remove("слиток") || index.Remove("слиток") {
+		t.Errorf("expected слиток to be removed exactly once")
+	}
+	expected = [][]string{{"пятак", "пятка", "тяпка"}}
+	if groups := index.Groups(); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected groups %v after removal, but got %v", expected, groups)
+	}
+}
+
+func TestAnagramIndexConcurrent(t *testing.T) {
+	index := NewAnagramIndex()
+	words := []string{"пятак", "пятка", "тяпка", "листок", "слиток", "столик"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, w := range words {
+				index.Add(w)
+				index.Lookup(w)
+				index.Groups()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if index.Len() != len(words) {
+		t.Errorf("expected %d words, but got %d", len(words), index.Len())
+	}
+}
@@ -0,0 +1,161 @@
+package anagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnicodeForm — форма нормализации Unicode, в которой хранятся слова.
+type UnicodeForm int
+
+const (
+	NoForm UnicodeForm = iota
+	NFC
+	NFD
+	NFKC
+	NFKD
+)
+
+// Normalizer задаёт, какие слова считаются одинаковыми и из каких букв они
+// состоят. Нулевое значение только приводит слова к нижнему регистру, как
+// FindAnagramSets.
+type Normalizer struct {
+	Form        UnicodeForm  // форма Unicode для хранения слов
+	Lang        language.Tag // язык для приведения к нижнему регистру (турецкое İ и т.п.)
+	FoldYo      bool         // считать ё и е одной буквой
+	FoldMarks   bool         // отбрасывать диакритические знаки: é -> e
+	LettersOnly bool         // не учитывать в сигнатуре пробелы, дефисы и знаки препинания
+}
+
+// DefaultNormalizer используется конструкторами без явного нормализатора.
+var DefaultNormalizer = &Normalizer{}
+
+// languageNormalizers — настройки по умолчанию для отдельных языков.
+var languageNormalizers = map[string]Normalizer{
+	"ru": {Form: NFC, Lang: language.Russian, FoldYo: true, LettersOnly: true},
+	"uk": {Form: NFC, Lang: language.Ukrainian, LettersOnly: true},
+	"en": {Form: NFC, Lang: language.English, FoldMarks: true, LettersOnly: true},
+	"de": {Form: NFC, Lang: language.German, LettersOnly: true},
+	"fr": {Form: NFC, Lang: language.French, FoldMarks: true, LettersOnly: true},
+	"tr": {Form: NFC, Lang: language.Turkish, LettersOnly: true},
+}
+
+// NormalizerFor возвращает настройки нормализации для языка lang. Для
+// языков без отдельных настроек используется NFC, приведение регистра по
+// правилам языка и только буквы в сигнатуре.
+func NormalizerFor(lang string) (*Normalizer, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, fmt.Errorf("unknown language %q: %w", lang, err)
+	}
+	base, _ := tag.Base()
+	n, ok := languageNormalizers[base.String()]
+	if !ok {
+		n = Normalizer{Form: NFC, Lang: tag, LettersOnly: true}
+	}
+	return &n, nil
+}
+
+// Word возвращает слово в каноническом виде: слова с одинаковым каноническим
+// видом считаются одним и тем же словом.
+func (n *Normalizer) Word(s string) string {
+	if *n == (Normalizer{}) {
+		return toLower(s)
+	}
+
+	s = norm.NFC.String(s)
+	if n.Lang == language.Und {
+		s = strings.ToLower(s)
+	} else {
+		s = cases.Lower(n.Lang).String(s)
+	}
+	if n.FoldYo {
+		s = strings.ReplaceAll(s, "ё", "е")
+	}
+	if n.FoldMarks {
+		s = strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+			return r
+		}, norm.NFD.String(s))
+		s = norm.NFC.String(s)
+	}
+
+	switch n.Form {
+	case NFD:
+		s = norm.NFD.String(s)
+	case NFKC:
+		s = norm.NFKC.String(s)
+	case NFKD:
+		s = norm.NFKD.String(s)
+	}
+	return s
+}
+
+// toLower — strings.ToLower, не копирующий строку без заглавных букв: в
+// словарях таких большинство, а ToLower копирует любую не-ASCII строку.
+func toLower(s string) string {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'а' && r <= 'я':
+			// Частые строчные буквы проверяются без таблиц Unicode.
+		case r >= 'A' && r <= 'Z', unicode.IsUpper(r), unicode.IsTitle(r):
+			return strings.ToLower(s)
+		}
+	}
+	return s
+}
+
+// Key возвращает буквенную сигнатуру слова: слова с одинаковой сигнатурой
+// являются анаграммами друг друга.
+func (n *Normalizer) Key(s string) string {
+	return n.signature(n.Word(s))
+}
+
+// signature — то же, что Key, для слова, уже приведённого к каноническому виду.
+func (n *Normalizer) signature(word string) string {
+	return sortString(n.letters(word, n.LettersOnly))
+}
+
+// letters возвращает символы слова s, из которых строится сигнатура: только
+// буквы и относящиеся к ним диакритические знаки, если lettersOnly
+// установлен. Символы берутся из NFC, какой бы ни была Form: в разложенной
+// форме знак отделён от своей буквы, и после сортировки "pâte" и "pêta"
+// получили бы одну сигнатуру.
+func (n *Normalizer) letters(s string, lettersOnly bool) string {
+	if n.Form == NFD || n.Form == NFKD {
+		s = norm.NFC.String(s)
+	}
+	if !lettersOnly {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// ParseUnicodeForm разбирает название формы нормализации: none, nfc, nfd,
+// nfkc или nfkd.
+func ParseUnicodeForm(s string) (UnicodeForm, error) {
+	forms := map[string]UnicodeForm{"none": NoForm, "nfc": NFC, "nfd": NFD, "nfkc": NFKC, "nfkd": NFKD}
+	f, ok := forms[strings.ToLower(s)]
+	if !ok {
+		names := make([]string, 0, len(forms))
+		for name := range forms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return NoForm, fmt.Errorf("unknown normalization form %q, want one of %s", s, strings.Join(names, ", "))
+	}
+	return f, nil
+}
@@ -0,0 +1,38 @@
+package anagram
+
+import (
+	"testing"
+)
+
+func TestNormalizerKey(t *testing.T) {
+	ru, _ := NormalizerFor("ru")
+	en, _ := NormalizerFor("en")
+	tr, _ := NormalizerFor("tr")
+	nfd := &Normalizer{Form: NFD}
+
+	tests := []struct {
+		norm  *Normalizer
+		a, b  string
+		equal bool
+	}{
+		{DefaultNormalizer, "Ёлка", "ёлка", true},
+		{DefaultNormalizer, "ёлка", "елка", false},
+		{ru, "ёлка", "ЕЛКА", true},
+		{ru, "кот-ток", "ток кот", true},
+		{DefaultNormalizer, "café", "cafe\u0301", false},
+		{nfd, "café", "cafe\u0301", true},
+		{nfd, "pâte", "pêta", false},
+		{nfd, "pâte", "tâpe", true},
+		{en, "Café", "face", true},
+		{en, "dirty room", "Dormitory!", true},
+		{tr, "İKİ", "iki", true},
+	}
+
+	for _, test := range tests {
+		equal := test.norm.Key(test.a) == test.norm.Key(test.b)
+		if equal != test.equal {
+			t.Errorf("%+v: expected equal=%v for %q and %q, but got keys %q and %q",
+				*test.norm, test.equal, test.a, test.b, test.norm.Key(test.a), test.norm.Key(test.b))
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package anagram
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SolveOptions ограничивает перебор в Solver.
+type SolveOptions struct {
+	MaxWords   int // максимум слов в одной анаграмме фразы (0 — без ограничения)
+	MaxResults int // максимум результатов (0 — без ограничения)
+	MinWordLen int // минимальная длина слова в буквах
+}
+
+// Solver ищет многословные анаграммы фраз ("dormitory" -> "dirty room") и
+// слова, которые можно составить из части заданных букв. Словарь
+// группируется по буквенной сигнатуре, как в FindAnagramSets, поэтому
+// перебираются сигнатуры, а не отдельные слова.
+type Solver struct {
+	norm   *Normalizer
+	groups []signatureGroup
+}
+
+// signatureGroup — слова с одной и той же сигнатурой.
+type signatureGroup struct {
+	signature string
+	length    int
+	words     []string
+}
+
+// NewSolver строит решатель по словарю с DefaultNormalizer. Повторы слов
+// отбрасываются.
+func NewSolver(words []string) *Solver {
+	return NewSolverWith(words, DefaultNormalizer)
+}
+
+// NewSolverWith строит решатель по словарю, сравнивающий слова по правилам n.
+// Слова без букв ("123", "--") отбрасываются: они не расходуют буквы фразы,
+// и перебор добавлял бы их бесконечно.
+func NewSolverWith(words []string, n *Normalizer) *Solver {
+	bySignature := make(map[string]*signatureGroup)
+	seen := make(map[string]bool)
+	for _, word := range words {
+		word = n.Word(word)
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		key := n.signature(word)
+		if key == "" {
+			continue
+		}
+		g, ok := bySignature[key]
+		if !ok {
+			g = &signatureGroup{signature: key, length: utf8.RuneCountInString(key)}
+			bySignature[key] = g
+		}
+		g.words = append(g.words, word)
+	}
+
+	s := &Solver{norm: n, groups: make([]signatureGroup, 0, len(bySignature))}
+	for _, g := range bySignature {
+		sort.Strings(g.words)
+		s.groups = append(s.groups, *g)
+	}
+	// Длинные слова первыми: так осмысленные анаграммы находятся раньше.
+	sort.Slice(s.groups, func(i, j int) bool {
+		if s.groups[i].length != s.groups[j].length {
+			return s.groups[i].length > s.groups[j].length
+		}
+		return s.groups[i].signature < s.groups[j].signature
+	})
+	return s
+}
+
+// letterPool — количество каждой буквы фразы. Буквы нумеруются в порядке
+// появления во фразе, поэтому слово с буквой вне фразы в пул не помещается.
+type letterPool struct {
+	index  map[rune]int
+	counts []int
+	total  int
+}
+
+func newLetterPool(letters string) *letterPool {
+	p := &letterPool{index: make(map[rune]int)}
+	for _, r := range letters {
+		i, ok := p.index[r]
+		if !ok {
+			i = len(p.counts)
+			p.index[r] = i
+			p.counts = append(p.counts, 0)
+		}
+		p.counts[i]++
+		p.total++
+	}
+	return p
+}
+
+// need переводит сигнатуру в счётчики букв пула. ok равно false, если в
+// сигнатуре есть буква, которой нет во фразе.
+func (p *letterPool) need(signature string) (counts []int, ok bool) {
+	counts = make([]int, len(p.counts))
+	for _, r := range signature {
+		i, found := p.index[r]
+		if !found {
+			return nil, false
+		}
+		counts[i]++
+	}
+	return counts, true
+}
+
+func (p *letterPool) fits(counts []int) bool {
+	for i, n := range counts {
+		if n > p.counts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *letterPool) take(counts []int, length int) {
+	for i, n := range counts {
+		p.counts[i] -= n
+	}
+	p.total -= length
+}
+
+func (p *letterPool) put(counts []int, length int) {
+	for i, n := range counts {
+		p.counts[i] += n
+	}
+	p.total += length
+}
+
+// candidate — группа словаря, помещающаяся в пул букв фразы.
+type candidate struct {
+	group  *signatureGroup
+	counts []int
+}
+
+func (s *Solver) candidates(pool *letterPool, minLen int) []candidate {
+	var result []candidate
+	for i := range s.groups {
+		g := &s.groups[i]
+		if g.length == 0 || g.length < minLen || g.length > pool.total {
+			continue
+		}
+		if counts, ok := pool.need(g.signature); ok && pool.fits(counts) {
+			result = append(result, candidate{group: g, counts: counts})
+		}
+	}
+	return result
+}
+
+// pool возвращает буквы фразы, приведённой к каноническому виду.
+func (s *Solver) pool(phrase string) *letterPool {
+	return newLetterPool(s.norm.letters(s.norm.Word(phrase), true))
+}
+
+// SubAnagrams возвращает слова словаря, составленные из части букв letters
+// (каждая буква используется не больше раз, чем встречается). Длинные слова
+// идут первыми.
+func (s *Solver) SubAnagrams(letters string, opts SolveOptions) []string {
+	var result []string
+	for _, c := range s.candidates(s.pool(letters), opts.MinWordLen) {
+		for _, w := range c.group.words {
+			if opts.MaxResults > 0 && len(result) >= opts.MaxResults {
+				return result
+			}
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// Phrases возвращает наборы слов, использующие все буквы фразы ровно по
+// одному разу. Пробелы и знаки препинания фразы игнорируются, а набор,
+// совпадающий со словами самой фразы, не возвращается.
+func (s *Solver) Phrases(phrase string, opts SolveOptions) [][]string {
+	pool := s.pool(phrase)
+	if pool.total == 0 {
+		return nil
+	}
+	st := &phraseSearch{
+		opts:     opts,
+		pool:     pool,
+		cands:    s.candidates(pool, opts.MinWordLen),
+		original: strings.Join(s.sortedWords(phrase), " "),
+	}
+	st.search(0)
+	return st.results
+}
+
+// phraseSearch — состояние перебора в Phrases.
+type phraseSearch struct {
+	opts     SolveOptions
+	pool     *letterPool
+	cands    []candidate
+	path     []int // индексы выбранных кандидатов по неубыванию
+	original string
+	results  [][]string
+}
+
+func (st *phraseSearch) full() bool {
+	return st.opts.MaxResults > 0 && len(st.results) >= st.opts.MaxResults
+}
+
+// search выбирает кандидатов начиная с from, чтобы каждый набор
+// сигнатур встречался один раз, а не во всех перестановках.
+func (st *phraseSearch) search(from int) {
+	if st.pool.total == 0 {
+		st.expand(0, 0, nil)
+		return
+	}
+	if st.opts.MaxWords > 0 && len(st.path) >= st.opts.MaxWords {
+		return
+	}
+	for i := from; i < len(st.cands) && !st.full(); i++ {
+		c := st.cands[i]
+		if c.group.length > st.pool.total || !st.pool.fits(c.counts) {
+			continue
+		}
+		st.pool.take(c.counts, c.group.length)
+		st.path = append(st.path, i)
+		st.search(i)
+		st.path = st.path[:len(st.path)-1]
+		st.pool.put(c.counts, c.group.length)
+	}
+}
+
+// expand перебирает слова для найденного набора сигнатур. Если одна
+// сигнатура выбрана несколько раз, слова для неё берутся по неубыванию,
+// чтобы не получать одинаковые наборы.
+func (st *phraseSearch) expand(pos, minWord int, words []string) {
+	if st.full() {
+		return
+	}
+	if pos == len(st.path) {
+		if strings.Join(sortedCopy(words), " ") != st.original {
+			st.results = append(st.results, append([]string(nil), words...))
+		}
+		return
+	}
+	if pos > 0 && st.path[pos] != st.path[pos-1] {
+		minWord = 0
+	}
+	group := st.cands[st.path[pos]].group
+	for j := minWord; j < len(group.words); j++ {
+		st.expand(pos+1, j, append(words, group.words[j]))
+	}
+}
+
+// sortedWords возвращает слова фразы в каноническом виде, отсортированные
+// по алфавиту.
+func (s *Solver) sortedWords(phrase string) []string {
+	return sortedCopy(strings.FieldsFunc(s.norm.Word(phrase), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r)
+	}))
+}
+
+func sortedCopy(words []string) []string {
+	result := append([]string(nil), words...)
+	sort.Strings(result)
+	return result
+}
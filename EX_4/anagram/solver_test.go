@@ -0,0 +1,40 @@
+package anagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSolver(t *testing.T) {
+	solver := NewSolver([]string{"dirty", "room", "dormitory", "moor", "dry", "tiro", "a", "i", "am"})
+
+	phrases := solver.Phrases("Dormitory", SolveOptions{MaxWords: 2})
+	expected := [][]string{{"dirty", "moor"}, {"dirty", "room"}}
+	if !reflect.DeepEqual(phrases, expected) {
+		t.Errorf("expected phrases %v, but got %v", expected, phrases)
+	}
+
+	if phrases := solver.Phrases("dormitory", SolveOptions{MaxResults: 1}); len(phrases) != 1 {
+		t.Errorf("expected 1 phrase with MaxResults, but got %v", phrases)
+	}
+
+	words := solver.SubAnagrams("dirty", SolveOptions{MinWordLen: 2})
+	expected2 := []string{"dirty", "dry"}
+	if !reflect.DeepEqual(words, expected2) {
+		t.Errorf("expected sub-anagrams %v, but got %v", expected2, words)
+	}
+}
+
+func TestSolverSkipsWordsWithoutLetters(t *testing.T) {
+	en, _ := NormalizerFor("en")
+	solver := NewSolverWith([]string{"dirty", "room", "123", "--"}, en)
+
+	phrases := solver.Phrases("dormitory", SolveOptions{})
+	expected := [][]string{{"dirty", "room"}}
+	if !reflect.DeepEqual(phrases, expected) {
+		t.Errorf("expected phrases %v, but got %v", expected, phrases)
+	}
+	if words := solver.SubAnagrams("dormitory", SolveOptions{}); len(words) != 2 {
+		t.Errorf("expected only dirty and room, but got %v", words)
+	}
+}
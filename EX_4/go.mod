@@ -0,0 +1,5 @@
+module EX_4
+
+go 1.22.3
+
+require golang.org/x/text v0.16.0
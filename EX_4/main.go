@@ -1,50 +1,218 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"sort"
+	"io"
+	"net/http"
+	"os"
 	"strings"
+	"unicode/utf8"
+
+	"EX_4/anagram"
 )
 
-// findAnagramSets находит все множества анаграмм в словаре.
-func findAnagramSets(words []string) map[string][]string {
-	anagrams := make(map[string][]string)
-	seen := make(map[string]bool)
-
-	for _, word := range words {
-		word = strings.ToLower(word)
-		sortedWord := sortString(word)
-		anagrams[sortedWord] = append(anagrams[sortedWord], word)
-	}
-
-	result := make(map[string][]string)
-	for _, group := range anagrams {
-		if len(group) > 1 {
-			sort.Strings(group)
-			key := group[0]
-			if !seen[key] {
-				result[key] = group
-				for _, w := range group {
-					seen[w] = true
-				}
+func main() {
+	format := flag.String("format", "text", "Формат вывода: text, json или csv")
+	dictFormat := flag.String("dict", "auto", "Формат словарей: auto, plain или hunspell")
+	minGroup := flag.Int("min-group", 2, "Минимальный размер группы анаграмм")
+	minLen := flag.Int("min-len", 0, "Минимальная длина слова в буквах")
+	maxLen := flag.Int("max-len", 0, "Максимальная длина слова в буквах (0 — без ограничения)")
+	lang := flag.String("lang", "", "Язык словаря для нормализации (ru, en, tr, ...)")
+	form := flag.String("norm", "", "Форма Unicode: none, nfc, nfd, nfkc или nfkd")
+	foldYo := flag.Bool("fold-yo", false, "Считать ё и е одной буквой")
+	foldMarks := flag.Bool("fold-marks", false, "Не различать буквы с диакритикой и без (é и e)")
+	lettersOnly := flag.Bool("letters-only", false, "Не учитывать пробелы и знаки препинания")
+	strategy := flag.String("strategy", "sorted", "Сигнатура слова: sorted (отсортированные буквы) или prime (произведение простых)")
+	workers := flag.Int("workers", 0, "Число параллельных обработчиков (0 — по числу процессоров)")
+	phrase := flag.String("phrase", "", "Найти многословные анаграммы фразы")
+	letters := flag.String("letters", "", "Найти слова, составленные из части этих букв")
+	maxWords := flag.Int("max-words", 3, "Максимум слов в анаграмме фразы (0 — без ограничения)")
+	limit := flag.Int("limit", 100, "Максимум результатов для -phrase, -letters и -near (0 — без ограничения)")
+	near := flag.String("near", "", "Найти почти-анаграммы слова")
+	distance := flag.Int("distance", 1, "Сколько букв можно добавить, убрать или заменить для -near")
+	serve := flag.String("serve", "", "Запустить HTTP-сервис анаграмм на этом адресе, например :8080")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [OPTIONS] [DICTIONARY]...\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Без словарей слова читаются из стандартного ввода.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	df, ok := map[string]anagram.DictFormat{
+		"auto":     anagram.AutoFormat,
+		"plain":    anagram.PlainFormat,
+		"hunspell": anagram.HunspellFormat,
+	}[*dictFormat]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown dictionary format %q\n", *dictFormat)
+		os.Exit(2)
+	}
+	write, ok := map[string]func(io.Writer, []anagram.Group) error{
+		"text": writeText,
+		"json": writeJSON,
+		"csv":  writeCSV,
+	}[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", *format)
+		os.Exit(2)
+	}
+
+	var words []string
+	var err error
+	if *serve == "" || flag.NArg() > 0 {
+		words, err = loadWords(flag.Args(), df)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	words = filterLength(words, *minLen, *maxLen)
+
+	normalizer := &anagram.Normalizer{}
+	if *lang != "" {
+		if normalizer, err = anagram.NormalizerFor(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if *form != "" {
+		if normalizer.Form, err = anagram.ParseUnicodeForm(*form); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	normalizer.FoldYo = normalizer.FoldYo || *foldYo
+	normalizer.FoldMarks = normalizer.FoldMarks || *foldMarks
+	normalizer.LettersOnly = normalizer.LettersOnly || *lettersOnly
+
+	if *serve != "" {
+		server := newAnagramServer(words, normalizer)
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", *serve)
+		if err := http.ListenAndServe(*serve, server.routes()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *near != "" {
+		index := anagram.NewAnagramIndexWith(normalizer)
+		for _, w := range words {
+			index.Add(w)
+		}
+		matches := index.LookupFuzzy(*near, *distance)
+		if *limit > 0 && len(matches) > *limit {
+			matches = matches[:*limit]
+		}
+		for _, m := range matches {
+			fmt.Printf("%s\t%d\t%.2f\n", m.Word, m.Distance, m.Score)
+		}
+		return
+	}
+
+	if *phrase != "" || *letters != "" {
+		solver := anagram.NewSolverWith(words, normalizer)
+		opts := anagram.SolveOptions{MaxWords: *maxWords, MaxResults: *limit, MinWordLen: *minLen}
+		if *phrase != "" {
+			for _, p := range solver.Phrases(*phrase, opts) {
+				fmt.Println(strings.Join(p, " "))
 			}
 		}
+		if *letters != "" {
+			for _, w := range solver.SubAnagrams(*letters, opts) {
+				fmt.Println(w)
+			}
+		}
+		return
+	}
+
+	opts := anagram.GroupOptions{Normalizer: normalizer, Workers: *workers}
+	switch *strategy {
+	case "sorted":
+		opts.Strategy = anagram.SortedRunes
+	case "prime":
+		opts.Strategy = anagram.PrimeProduct
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown strategy %q\n", *strategy)
+		os.Exit(2)
+	}
+
+	var groups []anagram.Group
+	for _, g := range anagram.GroupAnagrams(words, opts) {
+		if len(g.Words) >= *minGroup {
+			groups = append(groups, g)
+		}
+	}
+
+	if err := write(os.Stdout, groups); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadWords читает слова из всех словарей, а без них — из стандартного ввода.
+func loadWords(paths []string, format anagram.DictFormat) ([]string, error) {
+	if len(paths) == 0 {
+		if format == anagram.AutoFormat {
+			format = anagram.PlainFormat
+		}
+		return anagram.ReadDictionary(os.Stdin, format)
 	}
+	var words []string
+	for _, path := range paths {
+		w, err := anagram.LoadDictionary(path, format)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w...)
+	}
+	return words, nil
+}
 
+// filterLength оставляет слова длиной от minLen до maxLen букв.
+func filterLength(words []string, minLen, maxLen int) []string {
+	var result []string
+	for _, w := range words {
+		n := utf8.RuneCountInString(w)
+		if n < minLen || maxLen > 0 && n > maxLen {
+			continue
+		}
+		result = append(result, w)
+	}
 	return result
 }
 
-// sortString сортирует символы в строке в алфавитном порядке.
-func sortString(s string) string {
-	runes := []rune(s)
-	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
-	return string(runes)
+func writeText(w io.Writer, groups []anagram.Group) error {
+	for _, g := range groups {
+		if _, err := fmt.Fprintf(w, "Key: %s, Group: %v\n", g.Key, g.Words); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, groups []anagram.Group) error {
+	if groups == nil {
+		groups = []anagram.Group{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(groups)
 }
 
-func main() {
-	words := []string{"пятак", "пятка", "тяпка", "листок", "слиток", "столик", "кипяток", "питокя", "слово"}
-	anagramSets := findAnagramSets(words)
-	for key, group := range anagramSets {
-		fmt.Printf("Key: %s, Group: %v\n", key, group)
+// writeCSV выводит по строке на каждое слово: ключ группы и само слово.
+func writeCSV(w io.Writer, groups []anagram.Group) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"key", "word"})
+	for _, g := range groups {
+		for _, word := range g.Words {
+			cw.Write([]string{g.Key, word})
+		}
 	}
+	cw.Flush()
+	return cw.Error()
 }
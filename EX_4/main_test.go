@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"EX_4/anagram"
+)
+
+var testGroups = []anagram.Group{
+	{Key: "акптя", Words: []string{"пятак", "пятка"}},
+	{Key: "a,b", Words: []string{"a,b", "b,a"}},
+}
+
+func TestWriteText(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeText(&out, testGroups); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Key: акптя, Group: [пятак пятка]\nKey: a,b, Group: [a,b b,a]\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		groups   []anagram.Group
+		expected string
+	}{
+		{nil, "[]\n"},
+		{testGroups[:1], "[\n  {\n    \"key\": \"акптя\",\n    \"words\": [\n      \"пятак\",\n      \"пятка\"\n    ]\n  }\n]\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := writeJSON(&out, test.groups); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("expected %q, but got %q", test.expected, out.String())
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		groups   []anagram.Group
+		expected string
+	}{
+		{nil, "key,word\n"},
+		// Поля с запятыми берутся в кавычки.
+		{testGroups, "key,word\nакптя,пятак\nакптя,пятка\n\"a,b\",\"a,b\"\n\"a,b\",\"b,a\"\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := writeCSV(&out, test.groups); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("expected %q, but got %q", test.expected, out.String())
+		}
+	}
+}
+
+func TestFilterLength(t *testing.T) {
+	words := []string{"я", "кот", "ёжик", "пятак", "ab"}
+	tests := []struct {
+		minLen, maxLen int
+		expected       []string
+	}{
+		{0, 0, words},
+		{3, 0, []string{"кот", "ёжик", "пятак"}},
+		{0, 2, []string{"я", "ab"}},
+		// Длина считается в буквах, а не в байтах.
+		{4, 4, []string{"ёжик"}},
+		{6, 0, nil},
+	}
+
+	for _, test := range tests {
+		if result := filterLength(words, test.minLen, test.maxLen); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("min %d, max %d: expected %q, but got %q", test.minLen, test.maxLen, test.expected, result)
+		}
+	}
+}
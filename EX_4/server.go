@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"EX_4/anagram"
+)
+
+// maxUploadSize ограничивает размер загружаемого словаря.
+const maxUploadSize = 64 << 20
+
+// anagramServer отдаёт анаграммы по HTTP:
+//
+//	GET  /anagrams?word=пятак   — анаграммы слова из словаря
+//	GET  /groups?min=2          — все группы анаграмм
+//	POST /dictionary            — добавить слова из тела запроса
+//	PUT  /dictionary            — заменить словарь словами из тела запроса
+//
+// Формат тела задаётся параметром format: plain (по умолчанию) или hunspell.
+type anagramServer struct {
+	norm   *anagram.Normalizer
+	index  atomic.Pointer[anagram.AnagramIndex]
+	update sync.Mutex // упорядочивает изменения словаря (POST и PUT)
+}
+
+func newAnagramServer(words []string, norm *anagram.Normalizer) *anagramServer {
+	s := &anagramServer{norm: norm}
+	s.index.Store(s.newIndex(words))
+	return s
+}
+
+func (s *anagramServer) newIndex(words []string) *anagram.AnagramIndex {
+	index := anagram.NewAnagramIndexWith(s.norm)
+	for _, w := range words {
+		index.Add(w)
+	}
+	return index
+}
+
+func (s *anagramServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /anagrams", s.handleAnagrams)
+	mux.HandleFunc("GET /groups", s.handleGroups)
+	mux.HandleFunc("POST /dictionary", s.handleDictionary)
+	mux.HandleFunc("PUT /dictionary", s.handleDictionary)
+	return mux
+}
+
+type anagramsResponse struct {
+	Word     string   `json:"word"`
+	Anagrams []string `json:"anagrams"`
+}
+
+// handleAnagrams возвращает слова словаря из тех же букв, кроме самого слова.
+func (s *anagramServer) handleAnagrams(w http.ResponseWriter, r *http.Request) {
+	word := r.URL.Query().Get("word")
+	if word == "" {
+		respondError(w, http.StatusBadRequest, "missing word parameter")
+		return
+	}
+	self := s.norm.Word(word)
+	resp := anagramsResponse{Word: word, Anagrams: []string{}}
+	for _, a := range s.index.Load().Lookup(word) {
+		if a != self {
+			resp.Anagrams = append(resp.Anagrams, a)
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+type groupsResponse struct {
+	Groups [][]string `json:"groups"`
+}
+
+func (s *anagramServer) handleGroups(w http.ResponseWriter, r *http.Request) {
+	minSize := 2
+	if v := r.URL.Query().Get("min"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid min parameter %q", v))
+			return
+		}
+		minSize = n
+	}
+	resp := groupsResponse{Groups: [][]string{}}
+	for _, g := range s.index.Load().Groups() {
+		if len(g) >= minSize {
+			resp.Groups = append(resp.Groups, g)
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+type dictionaryResponse struct {
+	Added int `json:"added"`
+	Words int `json:"words"`
+}
+
+// handleDictionary добавляет слова в словарь (POST) или заменяет его (PUT).
+// Новый словарь строится отдельно — для POST это копия текущего — и
+// подменяется целиком, поэтому читающие запросы видят либо старый, либо
+// новый словарь, а не загруженный наполовину. Изменения выполняются по
+// одному, чтобы PUT не потерял слова параллельного POST и наоборот.
+func (s *anagramServer) handleDictionary(w http.ResponseWriter, r *http.Request) {
+	format := anagram.PlainFormat
+	switch f := r.URL.Query().Get("format"); f {
+	case "", "plain":
+	case "hunspell":
+		format = anagram.HunspellFormat
+	default:
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", f))
+		return
+	}
+
+	words, err := anagram.ReadDictionary(http.MaxBytesReader(w, r.Body, maxUploadSize), format)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.update.Lock()
+	var resp dictionaryResponse
+	if r.Method == http.MethodPut {
+		index := s.newIndex(words)
+		s.index.Store(index)
+		resp = dictionaryResponse{Added: index.Len(), Words: index.Len()}
+	} else {
+		index := s.index.Load().Clone()
+		for _, word := range words {
+			if index.Add(word) {
+				resp.Added++
+			}
+		}
+		s.index.Store(index)
+		resp.Words = index.Len()
+	}
+	s.update.Unlock()
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func respondJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func respondError(w http.ResponseWriter, status int, msg string) {
+	respondJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"EX_4/anagram"
+)
+
+func TestAnagramServer(t *testing.T) {
+	server := httptest.NewServer(newAnagramServer([]string{"пятак", "пятка", "слово"}, anagram.DefaultNormalizer).routes())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/dictionary?format=hunspell", "text/plain", strings.NewReader("2\nтяпка/AB\nлисток\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dict dictionaryResponse
+	json.NewDecoder(resp.Body).Decode(&dict)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || dict != (dictionaryResponse{Added: 2, Words: 5}) {
+		t.Errorf("expected 2 of 5 words added, but got %d %+v", resp.StatusCode, dict)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/anagrams?word=Пятак", http.StatusOK, `{"word":"Пятак","anagrams":["пятка","тяпка"]}`},
+		{"/anagrams?word=кот", http.StatusOK, `{"word":"кот","anagrams":[]}`},
+		{"/anagrams", http.StatusBadRequest, `{"error":"missing word parameter"}`},
+		{"/groups", http.StatusOK, `{"groups":[["пятак","пятка","тяпка"]]}`},
+		{"/groups?min=4", http.StatusOK, `{"groups":[]}`},
+		{"/groups?min=x", http.StatusBadRequest, `{"error":"invalid min parameter \"x\""}`},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(server.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, expected any
+		json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		json.Unmarshal([]byte(test.expected), &expected)
+		if resp.StatusCode != test.status || !reflect.DeepEqual(got, expected) {
+			t.Errorf("GET %s: expected %d %s, but got %d %v", test.path, test.status, test.expected, resp.StatusCode, got)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/dictionary", strings.NewReader("кот\nток\n"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&dict)
+	resp.Body.Close()
+	if dict != (dictionaryResponse{Added: 2, Words: 2}) {
+		t.Errorf("expected dictionary replaced with 2 words, but got %+v", dict)
+	}
+}
+
+func TestAnagramServerPostCopiesDictionary(t *testing.T) {
+	s := newAnagramServer([]string{"пятак"}, anagram.DefaultNormalizer)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	// Читающий запрос, начатый до загрузки, не должен увидеть её наполовину.
+	before := s.index.Load()
+	resp, err := http.Post(server.URL+"/dictionary", "text/plain", strings.NewReader("пятка\nтяпка\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if before.Len() != 1 || s.index.Load().Len() != 3 {
+		t.Errorf("expected the old index untouched and a new one with 3 words, but got %d and %d",
+			before.Len(), s.index.Load().Len())
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+)
+
+// ahoCorasick finds many literal patterns in one pass over a line, in time
+// linear in the line length however many patterns there are. Transitions
+// are kept sparse, so thousands of patterns need little memory.
+type ahoCorasick struct {
+	nodes     []acNode
+	foldASCII bool // compare ASCII letters case-insensitively
+	hasEmpty  bool // an empty pattern matches every line
+}
+
+type acNode struct {
+	edges  []acEdge // sorted by byte
+	fail   int32    // longest proper suffix that is also a trie path
+	output int32    // nearest node on the fail chain that ends a pattern, or -1
+	length int32    // length of the pattern ending here, 0 if none
+}
+
+type acEdge struct {
+	b    byte
+	next int32
+}
+
+func (n *acNode) child(b byte) int32 {
+	i := sort.Search(len(n.edges), func(i int) bool { return n.edges[i].b >= b })
+	if i < len(n.edges) && n.edges[i].b == b {
+		return n.edges[i].next
+	}
+	return -1
+}
+
+func newAhoCorasick(patterns []string, foldASCII bool) *ahoCorasick {
+	ac := &ahoCorasick{nodes: []acNode{{output: -1}}, foldASCII: foldASCII}
+	for _, p := range patterns {
+		if p == "" {
+			ac.hasEmpty = true
+			continue
+		}
+		cur := int32(0)
+		for i := 0; i < len(p); i++ {
+			b := ac.fold(p[i])
+			next := ac.nodes[cur].child(b)
+			if next < 0 {
+				next = int32(len(ac.nodes))
+				ac.nodes = append(ac.nodes, acNode{output: -1})
+				n := &ac.nodes[cur]
+				j := sort.Search(len(n.edges), func(j int) bool { return n.edges[j].b >= b })
+				n.edges = append(n.edges, acEdge{})
+				copy(n.edges[j+1:], n.edges[j:])
+				n.edges[j] = acEdge{b: b, next: next}
+			}
+			cur = next
+		}
+		ac.nodes[cur].length = int32(len(p))
+	}
+
+	// Breadth-first, so the fail target of a node is complete before the
+	// node's children are visited.
+	queue := []int32{0}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range ac.nodes[cur].edges {
+			child := &ac.nodes[e.next]
+			if cur != 0 {
+				f := ac.nodes[cur].fail
+				for f > 0 && ac.nodes[f].child(e.b) < 0 {
+					f = ac.nodes[f].fail
+				}
+				if next := ac.nodes[f].child(e.b); next >= 0 {
+					child.fail = next
+				}
+			}
+			if fail := &ac.nodes[child.fail]; fail.length > 0 {
+				child.output = child.fail
+			} else {
+				child.output = fail.output
+			}
+			queue = append(queue, e.next)
+		}
+	}
+	return ac
+}
+
+func (ac *ahoCorasick) fold(b byte) byte {
+	if ac.foldASCII && b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+func (ac *ahoCorasick) step(cur int32, b byte) int32 {
+	b = ac.fold(b)
+	for {
+		if next := ac.nodes[cur].child(b); next >= 0 {
+			return next
+		}
+		if cur == 0 {
+			return 0
+		}
+		cur = ac.nodes[cur].fail
+	}
+}
+
+// scan calls found with the range of every occurrence of every pattern in
+// line, ordered by end position. Scanning stops when found returns false.
+func (ac *ahoCorasick) scan(line string, found func(start, end int) bool) {
+	cur := int32(0)
+	for i := 0; i < len(line); i++ {
+		cur = ac.step(cur, line[i])
+		for n := cur; n > 0; n = ac.nodes[n].output {
+			if l := int(ac.nodes[n].length); l > 0 {
+				if !found(i+1-l, i+1) {
+					return
+				}
+			}
+		}
+	}
+}
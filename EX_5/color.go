@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// colors holds the SGR sequences used for highlighting, configured like GNU
+// grep through the GREP_COLORS environment variable, e.g.
+// GREP_COLORS='ms=01;32:fn=34:se=33'.
+type colors struct {
+	selectedMatch string // ms: matched text in selected lines
+	contextMatch  string // mc: matched text in context lines
+	fileName      string // fn
+	lineNum       string // ln
+	byteOffset    string // bn
+	separator     string // se
+}
+
+var defaultColors = colors{
+	selectedMatch: "01;31",
+	contextMatch:  "01;31",
+	fileName:      "35",
+	lineNum:       "32",
+	byteOffset:    "32",
+	separator:     "36",
+}
+
+// parseGrepColors applies a GREP_COLORS value to the default colors. Unknown
+// capabilities are ignored, as GNU grep does.
+func parseGrepColors(spec string) colors {
+	c := defaultColors
+	for _, item := range strings.Split(spec, ":") {
+		name, value, _ := strings.Cut(item, "=")
+		switch name {
+		case "mt":
+			c.selectedMatch, c.contextMatch = value, value
+		case "ms":
+			c.selectedMatch = value
+		case "mc":
+			c.contextMatch = value
+		case "fn":
+			c.fileName = value
+		case "ln":
+			c.lineNum = value
+		case "bn":
+			c.byteOffset = value
+		case "se":
+			c.separator = value
+		}
+	}
+	return c
+}
+
+// useColor decides whether to highlight output for --color=WHEN.
+func useColor(when string) (bool, error) {
+	switch when {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb", nil
+	}
+	return false, fmt.Errorf("invalid argument %q for --color, want auto, always or never", when)
+}
+
+// paint wraps s in the SGR sequence sgr, or returns it unchanged when sgr is
+// empty.
+func paint(s, sgr string) string {
+	if sgr == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + sgr + "m\x1b[K" + s + "\x1b[m\x1b[K"
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseGrepColors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected colors
+	}{
+		{"", defaultColors},
+		{"ms=01;32", colors{"01;32", "01;31", "35", "32", "32", "36"}},
+		{"mt=04:mc=07", colors{"04", "07", "35", "32", "32", "36"}},
+		{"fn=34:ln=33:bn=33:se=35", colors{"01;31", "01;31", "34", "33", "33", "35"}},
+		// An empty value turns the color off, unknown names are ignored.
+		{"se=:xx=1:ne", colors{"01;31", "01;31", "35", "32", "32", ""}},
+	}
+
+	for _, test := range tests {
+		if result := parseGrepColors(test.spec); result != test.expected {
+			t.Errorf("expected %+v for %q, but got %+v", test.expected, test.spec, result)
+		}
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		when     string
+		expected bool
+		err      bool
+	}{
+		{"always", true, false},
+		{"never", false, false},
+		{"sometimes", false, true},
+		{"", false, true},
+	}
+
+	for _, test := range tests {
+		result, err := useColor(test.when)
+		if result != test.expected || (err != nil) != test.err {
+			t.Errorf("expected %v (error %v) for %q, but got %v, %v", test.expected, test.err, test.when, result, err)
+		}
+	}
+	if _, err := useColor("auto"); err != nil {
+		t.Errorf("expected no error for auto, but got %v", err)
+	}
+}
+
+func TestPrinterLineColors(t *testing.T) {
+	palette := parseGrepColors("ms=01;31:mc=01;32")
+	m, err := newMatcher([]string{"foo"}, GrepOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		options  GrepOptions
+		text     string
+		sep      byte
+		expected string
+	}{
+		{GrepOptions{}, "a foo b foo", matchSep,
+			"a \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K b \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K\n"},
+		// Context lines of a normal search do not match.
+		{GrepOptions{}, "bar", contextSep, "bar\n"},
+		// With -v the selected lines do not match and the matches are in the
+		// context lines, highlighted with mc.
+		{GrepOptions{invert: true}, "bar", matchSep, "bar\n"},
+		{GrepOptions{invert: true}, "a foo", contextSep, "a \x1b[01;32m\x1b[Kfoo\x1b[m\x1b[K\n"},
+		{GrepOptions{lineNum: true}, "foo", matchSep,
+			"\x1b[32m\x1b[K7\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[K\x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K\n"},
+		{GrepOptions{lineNum: true}, "x", contextSep,
+			"\x1b[32m\x1b[K7\x1b[m\x1b[K\x1b[36m\x1b[K-\x1b[m\x1b[Kx\n"},
+	}
+
+	for _, test := range tests {
+		test.options.colors = &palette
+		var out bytes.Buffer
+		p := newPrinter(&out, test.options)
+		p.line("f", contextLine{num: 7, text: test.text}, test.sep, m)
+		p.flush()
+		if out.String() != test.expected {
+			t.Errorf("%q %c %+v: expected %q, but got %q", test.text, test.sep, test.options, test.expected, out.String())
+		}
+	}
+}
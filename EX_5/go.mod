@@ -0,0 +1,3 @@
+module EX_5
+
+go 1.22.3
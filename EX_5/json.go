@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// The --json output follows ripgrep's JSON Lines format: for every file with
+// selected lines a "begin" record, a "match" or "context" record per printed
+// line and an "end" record with the file's statistics, then one "summary"
+// record for the whole run.
+
+type jsonMessage struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// jsonText is arbitrary data: {"text": ...} when it is valid UTF-8,
+// {"bytes": base64} otherwise.
+type jsonText string
+
+func (t jsonText) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(t)) {
+		return json.Marshal(map[string]string{"text": string(t)})
+	}
+	return json.Marshal(map[string]string{"bytes": base64.StdEncoding.EncodeToString([]byte(t))})
+}
+
+type jsonBegin struct {
+	Path jsonText `json:"path"`
+}
+
+type jsonSubmatch struct {
+	Match jsonText `json:"match"`
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+}
+
+type jsonLine struct {
+	Path           jsonText       `json:"path"`
+	Lines          jsonText       `json:"lines"`
+	LineNumber     int            `json:"line_number"`
+	AbsoluteOffset int64          `json:"absolute_offset"`
+	Submatches     []jsonSubmatch `json:"submatches"`
+}
+
+type jsonEnd struct {
+	Path         jsonText  `json:"path"`
+	BinaryOffset *int64    `json:"binary_offset"`
+	Stats        jsonStats `json:"stats"`
+}
+
+type jsonStats struct {
+	Searches          int   `json:"searches"`
+	SearchesWithMatch int   `json:"searches_with_match"`
+	BytesSearched     int64 `json:"bytes_searched"`
+	MatchedLines      int   `json:"matched_lines"`
+	Matches           int   `json:"matches"`
+}
+
+func (s *jsonStats) add(other jsonStats) {
+	s.Searches += other.Searches
+	s.SearchesWithMatch += other.SearchesWithMatch
+	s.BytesSearched += other.BytesSearched
+	s.MatchedLines += other.MatchedLines
+	s.Matches += other.Matches
+}
+
+type jsonDuration struct {
+	Secs  int64  `json:"secs"`
+	Nanos int    `json:"nanos"`
+	Human string `json:"human"`
+}
+
+type jsonSummary struct {
+	ElapsedTotal jsonDuration `json:"elapsed_total"`
+	Stats        jsonStats    `json:"stats"`
+}
+
+// writeJSON writes one record on a line of its own.
+func (p *printer) writeJSON(kind string, data any) {
+	b, err := json.Marshal(jsonMessage{Type: kind, Data: data})
+	if err != nil {
+		panic(err) // all records are plain data
+	}
+	p.w.Write(b)
+	p.w.WriteByte('\n')
+}
+
+// beginFile writes the "begin" record for a file unless it is written already.
+func (p *printer) beginFile(name string) {
+	if !p.begun {
+		p.writeJSON("begin", jsonBegin{Path: jsonText(name)})
+		p.begun = true
+	}
+}
+
+// jsonLine writes a selected (sep ':') or context (sep '-') line with the
+// ranges matched by m in it.
+func (p *printer) jsonLine(name string, l contextLine, sep byte, m matcher) {
+	p.beginFile(name)
+	record := jsonLine{
+		Path:           jsonText(name),
+		Lines:          jsonText(l.text + l.eol),
+		LineNumber:     l.num,
+		AbsoluteOffset: l.offset,
+		Submatches:     []jsonSubmatch{},
+	}
+	for _, loc := range m.findAll(l.text) {
+		record.Submatches = append(record.Submatches, jsonSubmatch{
+			Match: jsonText(l.text[loc[0]:loc[1]]),
+			Start: loc[0],
+			End:   loc[1],
+		})
+	}
+	kind := "context"
+	if sep == matchSep {
+		kind = "match"
+		p.fileStats.Matches += len(record.Submatches)
+	}
+	p.writeJSON(kind, record)
+}
+
+// endFile finishes the search of a file: the number of selected lines and
+// the bytes read are added to the statistics, and with --json the "end"
+// record is written for a file that had lines printed.
+func (p *printer) endFile(name string, selected int, bytesRead int64) {
+	p.fileStats.Searches = 1
+	p.fileStats.BytesSearched = bytesRead
+	p.fileStats.MatchedLines = selected
+	if selected > 0 {
+		p.fileStats.SearchesWithMatch = 1
+	}
+	if p.options.json && p.begun {
+		p.writeJSON("end", jsonEnd{Path: jsonText(name), BinaryOffset: p.binaryOffset, Stats: p.fileStats})
+	}
+	p.stats.add(p.fileStats)
+	p.fileStats, p.begun, p.binaryOffset = jsonStats{}, false, nil
+}
+
+// summary writes the closing --json record with the statistics of all files.
+func (p *printer) summary(elapsed time.Duration) {
+	p.writeJSON("summary", jsonSummary{
+		ElapsedTotal: jsonDuration{
+			Secs:  int64(elapsed / time.Second),
+			Nanos: int(elapsed % time.Second),
+			Human: fmt.Sprintf("%.6fs", elapsed.Seconds()),
+		},
+		Stats: p.stats,
+	})
+}
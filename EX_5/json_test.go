@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONOutput(t *testing.T) {
+	options := GrepOptions{json: true, after: 1, maxCount: -1}
+	m, err := newMatcher([]string{"foo"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p := newPrinter(&out, options)
+	inputs := []struct{ name, text string }{
+		{"a", "x foo foo\nnext\nnone\n"},
+		{"b", "nothing here\n"},
+		{"c", "foo\x00\n"},
+	}
+	for _, in := range inputs {
+		if _, err := search(strings.NewReader(in.text), in.name, p, m, options); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.summary(1500 * time.Millisecond)
+	p.flush()
+
+	expected := []string{
+		`{"type":"begin","data":{"path":{"text":"a"}}}`,
+		`{"type":"match","data":{"path":{"text":"a"},"lines":{"text":"x foo foo\n"},"line_number":1,"absolute_offset":0,` +
+			`"submatches":[{"match":{"text":"foo"},"start":2,"end":5},{"match":{"text":"foo"},"start":6,"end":9}]}}`,
+		`{"type":"context","data":{"path":{"text":"a"},"lines":{"text":"next\n"},"line_number":2,"absolute_offset":10,"submatches":[]}}`,
+		`{"type":"end","data":{"path":{"text":"a"},"binary_offset":null,` +
+			`"stats":{"searches":1,"searches_with_match":1,"bytes_searched":20,"matched_lines":1,"matches":2}}}`,
+		`{"type":"begin","data":{"path":{"text":"c"}}}`,
+		`{"type":"end","data":{"path":{"text":"c"},"binary_offset":3,` +
+			`"stats":{"searches":1,"searches_with_match":1,"bytes_searched":5,"matched_lines":1,"matches":0}}}`,
+		`{"type":"summary","data":{"elapsed_total":{"secs":1,"nanos":500000000,"human":"1.500000s"},` +
+			`"stats":{"searches":3,"searches_with_match":2,"bytes_searched":38,"matched_lines":2,"matches":2}}}`,
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d records, but got %d:\n%s", len(expected), len(lines), out.String())
+	}
+	for i := range lines {
+		var got, want any
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatalf("record %d is not JSON: %v: %s", i, err, lines[i])
+		}
+		json.Unmarshal([]byte(expected[i]), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("record %d: expected\n%s\nbut got\n%s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestJSONLineTerminator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"foo", []string{"foo"}},
+		{"foo\n", []string{"foo\n"}},
+		{"foo\r\n", []string{"foo\r\n"}},
+		{"a foo\nfoo", []string{"a foo\n", "foo"}},
+	}
+
+	for _, test := range tests {
+		options := GrepOptions{json: true, maxCount: -1}
+		m, _ := newMatcher([]string{"foo"}, options)
+		var out bytes.Buffer
+		p := newPrinter(&out, options)
+		if _, err := search(strings.NewReader(test.input), "-", p, m, options); err != nil {
+			t.Fatal(err)
+		}
+		p.flush()
+
+		var result []string
+		dec := json.NewDecoder(&out)
+		for dec.More() {
+			var record struct {
+				Type string
+				Data struct{ Lines struct{ Text string } }
+			}
+			if err := dec.Decode(&record); err != nil {
+				t.Fatal(err)
+			}
+			if record.Type == "match" {
+				result = append(result, record.Data.Lines.Text)
+			}
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("expected lines %q for %q, but got %q", test.expected, test.input, result)
+		}
+	}
+}
+
+func TestJSONText(t *testing.T) {
+	tests := []struct {
+		text     jsonText
+		expected string
+	}{
+		{"plain", `{"text":"plain"}`},
+		{"", `{"text":""}`},
+		{"\xff foo", `{"bytes":"/yBmb28="}`},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(test.text)
+		if err != nil || string(b) != test.expected {
+			t.Errorf("expected %s for %q, but got %s, %v", test.expected, string(test.text), b, err)
+		}
+	}
+}
@@ -1,126 +1,259 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
-	"strings"
+	"time"
 )
 
 type GrepOptions struct {
-	after      int
-	before     int
-	context    int
-	count      bool
-	ignoreCase bool
-	invert     bool
-	fixed      bool
-	lineNum    bool
+	after           int
+	before          int
+	separate        bool
+	groupSeparator  string
+	count           bool
+	ignoreCase      bool
+	invert          bool
+	fixed           bool
+	extended        bool
+	basic           bool
+	wordMatch       bool
+	lineMatch       bool
+	lineNum         bool
+	recursive       bool
+	withName        bool
+	include         globList
+	exclude         globList
+	excludeDir      globList
+	onlyMatching    bool
+	byteOffset      bool
+	maxCount        int
+	listMatching    bool
+	listNonMatching bool
+	quiet           bool
+	suppressErrors  bool
+	json            bool
+	colors          *colors
 }
 
 func main() {
 	after := flag.Int("A", 0, "Print +N lines after match")
 	before := flag.Int("B", 0, "Print +N lines before match")
-	context := flag.Int("C", 0, "Print ±N lines around match")
+	context := flag.Int("C", 0, "Print ±N lines around match (-A and -B take precedence)")
+	groupSeparator := flag.String("group-separator", "--", "Print SEP between groups of context lines")
+	noGroupSeparator := flag.Bool("no-group-separator", false, "Do not print a separator between groups")
 	count := flag.Bool("c", false, "Count lines")
+	maxCount := flag.Int("m", -1, "Stop reading a file after NUM selected lines")
+	listMatching := flag.Bool("l", false, "Print only the names of files with selected lines")
+	listNonMatching := flag.Bool("L", false, "Print only the names of files without selected lines")
 	ignoreCase := flag.Bool("i", false, "Ignore case")
 	invert := flag.Bool("v", false, "Invert match")
 	fixed := flag.Bool("F", false, "Fixed string match")
+	extended := flag.Bool("E", false, "PATTERN is an extended regular expression")
+	basic := flag.Bool("G", false, "PATTERN is a basic regular expression")
+	wordMatch := flag.Bool("w", false, "Match only whole words")
+	lineMatch := flag.Bool("x", false, "Match only whole lines")
+	var patterns patternList
+	var patternFiles patternFiles
+	flag.Var(&patterns, "e", "Use PATTERN for matching (repeatable)")
+	flag.Var(&patternFiles, "f", "Take patterns from FILE, one per line (repeatable)")
+	quiet := flag.Bool("q", false, "Print nothing, exit 0 at the first match")
+	suppressErrors := flag.Bool("s", false, "Suppress error messages about unreadable files")
 	lineNum := flag.Bool("n", false, "Print line number")
+	onlyMatching := flag.Bool("o", false, "Print only the matched parts of a line")
+	byteOffset := flag.Bool("b", false, "Print the byte offset of each line (of each match with -o)")
+	color := flag.String("color", "never", "Highlight matches: auto, always or never (colors from GREP_COLORS)")
+	jsonOutput := flag.Bool("json", false, "Print results as JSON Lines in ripgrep's format")
+	recursive := flag.Bool("r", false, "Search directories recursively")
+	withName := flag.Bool("H", false, "Print the file name for each match")
+	noName := flag.Bool("h", false, "Never print file names")
+	var include, exclude, excludeDir globList
+	flag.Var(&include, "include", "Search only files whose base name matches GLOB (repeatable)")
+	flag.Var(&exclude, "exclude", "Skip files whose base name matches GLOB (repeatable)")
+	flag.Var(&excludeDir, "exclude-dir", "Skip directories matching GLOB when recursing (repeatable)")
 	flag.Parse()
 
-	if flag.NArg() < 2 {
-		fmt.Println("Usage: grep [OPTIONS] PATTERN FILE")
-		flag.Usage()
-		return
+	// With -e or -f every argument is a file, otherwise the first one is
+	// the pattern.
+	files := flag.Args()
+	if len(patterns) == 0 && len(patternFiles) == 0 {
+		if flag.NArg() < 1 {
+			fmt.Println("Usage: grep [OPTIONS] PATTERN [FILE]...")
+			fmt.Println("       grep [OPTIONS] -e PATTERN... [-f FILE]... [FILE]...")
+			flag.Usage()
+			os.Exit(exitError)
+		}
+		patterns = splitPatterns(files[0])
+		files = files[1:]
 	}
+	fromFiles, err := patternFiles.read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	patterns = append(patterns, fromFiles...)
 
-	pattern := flag.Arg(0)
-	filePath := flag.Arg(1)
+	if len(files) == 0 {
+		files = defaultInputs(*recursive)
+	}
 
-	options := GrepOptions{
-		after:      *after,
-		before:     *before,
-		context:    *context,
-		count:      *count,
-		ignoreCase: *ignoreCase,
-		invert:     *invert,
-		fixed:      *fixed,
-		lineNum:    *lineNum,
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	*after, *before, err = contextLines(*after, *before, *context, explicit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "grep: %v\n", err)
+		os.Exit(exitError)
 	}
 
-	err := grep(pattern, filePath, options)
+	colorOn, err := useColor(*color)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if *jsonOutput && (*count || *listMatching || *listNonMatching || *onlyMatching) {
+		fmt.Fprintln(os.Stderr, "Error: --json cannot be combined with -c, -l, -L or -o")
+		os.Exit(exitError)
+	}
+	var palette *colors
+	if colorOn && !*jsonOutput {
+		c := parseGrepColors(os.Getenv("GREP_COLORS"))
+		palette = &c
+	}
+
+	options := GrepOptions{
+		after:           *after,
+		before:          *before,
+		separate:        !*noGroupSeparator,
+		groupSeparator:  *groupSeparator,
+		count:           *count,
+		ignoreCase:      *ignoreCase,
+		invert:          *invert,
+		fixed:           *fixed,
+		extended:        *extended,
+		basic:           *basic,
+		wordMatch:       *wordMatch,
+		lineMatch:       *lineMatch,
+		lineNum:         *lineNum,
+		recursive:       *recursive,
+		withName:        (len(files) > 1 || *recursive || *withName) && !*noName,
+		include:         include,
+		exclude:         exclude,
+		excludeDir:      excludeDir,
+		onlyMatching:    *onlyMatching,
+		byteOffset:      *byteOffset,
+		maxCount:        *maxCount,
+		listMatching:    *listMatching,
+		listNonMatching: *listNonMatching,
+		quiet:           *quiet,
+		suppressErrors:  *suppressErrors,
+		json:            *jsonOutput,
+		colors:          palette,
 	}
-}
 
-func grep(pattern, filePath string, options GrepOptions) error {
-	file, err := os.Open(filePath)
+	status, err := grep(patterns, files, options)
 	if err != nil {
-		return err
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
-	defer file.Close()
+	os.Exit(status)
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// contextLines returns the -A and -B context sizes. Like GNU grep, an
+// explicit -A or -B wins over -C regardless of order; explicit holds the
+// names of the flags given on the command line. A negative size is an error.
+func contextLines(after, before, context int, explicit map[string]bool) (int, int, error) {
+	for _, n := range []int{after, before, context} {
+		if n < 0 {
+			return 0, 0, fmt.Errorf("%d: invalid context length argument", n)
+		}
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+	if !explicit["A"] {
+		after = context
 	}
+	if !explicit["B"] {
+		before = context
+	}
+	return after, before, nil
+}
+
+// Exit statuses, as in GNU grep.
+const (
+	exitMatch   = 0 // a line was selected
+	exitNoMatch = 1 // no line was selected
+	exitError   = 2 // an error occurred, unless -q found a match
+)
 
-	if options.context > 0 {
-		options.after = options.context
-		options.before = options.context
+// grep searches the files and returns the exit status. Errors about single
+// files are reported as they occur; only setup errors are returned.
+func grep(patterns []string, files []string, options GrepOptions) (int, error) {
+	m, err := newMatcher(patterns, options)
+	if err != nil {
+		return exitError, err
 	}
 
-	var matches []int
-	for i, line := range lines {
-		if match(line, pattern, options) {
-			matches = append(matches, i)
-		}
+	start := time.Now()
+	p := newPrinter(os.Stdout, options)
+	defer p.flush()
+
+	// A single file is streamed straight to the output, so that matches from
+	// a slow stream such as tail -f show up at once.
+	var matched, failed bool
+	if len(files) > 1 || options.recursive {
+		matched, failed = grepParallel(files, p, m, options)
+	} else {
+		walkInputs(files, options, func(path string, err error) bool {
+			found := false
+			if err == nil {
+				found, err = grepFile(path, p, m, options)
+			}
+			matched = matched || found
+			failed = reportError(path, err, options) || failed
+			// With -q the first match decides the result.
+			return !(options.quiet && matched)
+		})
 	}
 
-	if options.count {
-		fmt.Println(len(matches))
-		return nil
+	if options.json && !options.quiet {
+		p.summary(time.Since(start))
 	}
 
-	printMatches(lines, matches, options)
-	return nil
+	switch {
+	case failed && !(options.quiet && matched):
+		return exitError, nil
+	case matched:
+		return exitMatch, nil
+	}
+	return exitNoMatch, nil
 }
 
-func match(line, pattern string, options GrepOptions) bool {
-	if options.ignoreCase {
-		line = strings.ToLower(line)
-		pattern = strings.ToLower(pattern)
+// reportError prints an error about path, if any, unless -s is given. It
+// reports whether there was an error.
+func reportError(path string, err error, options GrepOptions) bool {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		// The path is printed already, keep only the cause.
+		err = pathErr.Err
 	}
-	if options.fixed {
-		return options.invert != strings.Contains(line, pattern)
+	if err != nil && !options.suppressErrors {
+		fmt.Fprintf(os.Stderr, "grep: %s: %v\n", path, err)
 	}
-	return options.invert != strings.Contains(line, pattern)
+	return err != nil
 }
 
-func printMatches(lines []string, matches []int, options GrepOptions) {
-	printed := make(map[int]bool)
-	for _, match := range matches {
-		start := max(0, match-options.before)
-		end := min(len(lines), match+options.after+1)
-
-		for i := start; i < end; i++ {
-			if printed[i] {
-				continue
-			}
-			printed[i] = true
-			if options.lineNum {
-				fmt.Printf("%d:", i+1)
-			}
-			fmt.Println(lines[i])
-		}
+// grepFile searches one file, or standard input when path is "-".
+func grepFile(path string, p *printer, m matcher, options GrepOptions) (bool, error) {
+	if path == "-" {
+		return search(os.Stdin, "(standard input)", p, m, options)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
 	}
+	defer file.Close()
+	return search(file, path, p, m, options)
 }
 
 func max(a, b int) int {
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGrepExitStatus(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f")
+	if err := os.WriteFile(file, []byte("foo\nbar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	tests := []struct {
+		pattern  string
+		files    []string
+		options  GrepOptions
+		expected int
+	}{
+		{"foo", []string{file}, GrepOptions{}, exitMatch},
+		{"baz", []string{file}, GrepOptions{}, exitNoMatch},
+		{"foo", []string{file}, GrepOptions{invert: true}, exitMatch},
+		{"foo|bar", []string{file}, GrepOptions{invert: true, extended: true}, exitNoMatch},
+		{"baz", []string{file}, GrepOptions{count: true}, exitNoMatch},
+		{"foo", []string{missing}, GrepOptions{}, exitError},
+		{"foo", []string{missing, file}, GrepOptions{}, exitError},
+		{"foo", []string{missing}, GrepOptions{suppressErrors: true}, exitError},
+		{"foo", []string{missing, file}, GrepOptions{quiet: true}, exitMatch},
+		{"foo", []string{file, missing}, GrepOptions{quiet: true}, exitMatch},
+		{"baz", []string{file, missing}, GrepOptions{quiet: true}, exitError},
+		{"foo", []string{dir}, GrepOptions{recursive: true, quiet: true}, exitMatch},
+		{"foo", []string{file}, GrepOptions{maxCount: 1}, exitMatch},
+	}
+
+	for _, test := range tests {
+		if test.options.maxCount == 0 {
+			test.options.maxCount = -1
+		}
+		status, err := grep([]string{test.pattern}, test.files, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != test.expected {
+			t.Errorf("%q %q %+v: expected exit status %d, but got %d", test.pattern, test.files, test.options, test.expected, status)
+		}
+	}
+
+	// -m 0 reads nothing, so nothing is selected.
+	if status, _ := grep([]string{"foo"}, []string{file}, GrepOptions{}); status != exitNoMatch {
+		t.Errorf("expected exit status %d with -m 0, but got %d", exitNoMatch, status)
+	}
+	if status, err := grep([]string{"a("}, []string{file}, GrepOptions{extended: true}); err == nil || status != exitError {
+		t.Errorf("expected exit status %d and an error for an invalid pattern, but got %d, %v", exitError, status, err)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	tests := []struct {
+		err      error
+		options  GrepOptions
+		expected string
+	}{
+		{nil, GrepOptions{}, ""},
+		{errors.New("broken"), GrepOptions{}, "grep: p: broken\n"},
+		// The path is not repeated from a *fs.PathError.
+		{&fs.PathError{Op: "open", Path: "p", Err: fs.ErrNotExist}, GrepOptions{}, "grep: p: file does not exist\n"},
+		{errors.New("broken"), GrepOptions{suppressErrors: true}, ""},
+	}
+
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+	for _, test := range tests {
+		f, err := os.CreateTemp(t.TempDir(), "stderr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stderr = f
+		failed := reportError("p", test.err, test.options)
+		os.Stderr = stderr
+		f.Close()
+
+		out, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != test.expected {
+			t.Errorf("%v %+v: expected %q on stderr, but got %q", test.err, test.options, test.expected, out)
+		}
+		if failed != (test.err != nil) {
+			t.Errorf("%v: expected failure %v, but got %v", test.err, test.err != nil, failed)
+		}
+	}
+}
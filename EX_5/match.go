@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matcher reports whether a line matches the search pattern and where. It
+// is built once per run, so a regular expression is compiled only once.
+type matcher interface {
+	match(line string) bool
+	// findAll returns the byte ranges of the non-empty, non-overlapping
+	// matches in line, from left to right.
+	findAll(line string) [][]int
+}
+
+// literalMatcher matches a set of literal strings: with -F, or when no
+// pattern contains a regular expression operator. Several patterns are
+// searched for at once with an Aho–Corasick automaton.
+type literalMatcher struct {
+	patterns  []string
+	ac        *ahoCorasick
+	foldASCII bool
+	word      bool            // -w: matches must be whole words
+	lines     map[string]bool // -x: the (folded) patterns, matched against whole lines
+}
+
+func newLiteralMatcher(patterns []string, options GrepOptions) *literalMatcher {
+	m := &literalMatcher{patterns: patterns, foldASCII: options.ignoreCase, word: options.wordMatch}
+	if options.lineMatch {
+		m.lines = make(map[string]bool, len(patterns))
+		for _, p := range patterns {
+			m.lines[m.fold(p)] = true
+		}
+		return m
+	}
+	if len(patterns) > 1 || m.foldASCII {
+		m.ac = newAhoCorasick(patterns, m.foldASCII)
+	}
+	return m
+}
+
+func (m *literalMatcher) fold(s string) string {
+	if !m.foldASCII {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+func (m *literalMatcher) match(line string) bool {
+	switch {
+	case m.lines != nil:
+		return m.lines[m.fold(line)]
+	case m.word:
+		return len(m.findAll(line)) > 0
+	case m.ac == nil:
+		return strings.Contains(line, m.patterns[0])
+	case m.ac.hasEmpty:
+		return true
+	}
+	found := false
+	m.ac.scan(line, func(start, end int) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+func (m *literalMatcher) findAll(line string) [][]int {
+	if m.lines != nil {
+		if line != "" && m.lines[m.fold(line)] {
+			return [][]int{{0, len(line)}}
+		}
+		return nil
+	}
+
+	var all [][]int
+	add := func(start, end int) bool {
+		if !m.word || wordBounded(line, start, end) {
+			all = append(all, []int{start, end})
+		}
+		return true
+	}
+	if m.ac == nil {
+		if p := m.patterns[0]; p != "" {
+			for start := 0; ; start++ {
+				i := strings.Index(line[start:], p)
+				if i < 0 {
+					break
+				}
+				start += i
+				add(start, start+len(p))
+			}
+		}
+	} else {
+		m.ac.scan(line, add)
+	}
+	return leftmostLongest(all)
+}
+
+// leftmostLongest picks non-overlapping matches from all occurrences: the
+// leftmost one first and of those starting there the longest, like a POSIX
+// regular expression search.
+func leftmostLongest(all [][]int) [][]int {
+	sort.Slice(all, func(i, j int) bool {
+		if all[i][0] != all[j][0] {
+			return all[i][0] < all[j][0]
+		}
+		return all[i][1] > all[j][1]
+	})
+	var result [][]int
+	end := 0
+	for _, loc := range all {
+		if loc[0] >= end {
+			result = append(result, loc)
+			end = loc[1]
+		}
+	}
+	return result
+}
+
+// wordBounded reports whether line[start:end] is neither preceded nor
+// followed by a word constituent (letter, digit or underscore), as -w
+// requires.
+func wordBounded(line string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(line[:start])
+	after, _ := utf8.DecodeRuneInString(line[end:])
+	return (start == 0 || !isWordRune(before)) && (end == len(line) || !isWordRune(after))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// noMatcher matches no line at all.
+type noMatcher struct{}
+
+func (noMatcher) match(string) bool      { return false }
+func (noMatcher) findAll(string) [][]int { return nil }
+
+// regexpMatcher matches a compiled regular expression.
+type regexpMatcher struct {
+	re   *regexp.Regexp
+	word bool // -w: matches must be whole words
+}
+
+func (m *regexpMatcher) match(line string) bool {
+	if m.word {
+		return len(m.findAll(line)) > 0
+	}
+	return m.re.MatchString(line)
+}
+
+func (m *regexpMatcher) findAll(line string) [][]int {
+	var result [][]int
+	for _, loc := range m.re.FindAllStringIndex(line, -1) {
+		if loc[0] < loc[1] && (!m.word || wordBounded(line, loc[0], loc[1])) {
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// newMatcher builds a matcher for the patterns in the syntax selected by
+// options: literal strings with -F, POSIX extended regular expressions with
+// -E, POSIX basic regular expressions with -G and Go RE2 syntax otherwise. A
+// line matches if any pattern matches it. Patterns without any operator are
+// searched for as literals.
+func newMatcher(patterns []string, options GrepOptions) (matcher, error) {
+	if len(patterns) == 0 {
+		// An empty pattern file selects nothing.
+		return noMatcher{}, nil
+	}
+	literal := true
+	for _, p := range patterns {
+		if !options.fixed && regexp.QuoteMeta(p) != p || options.ignoreCase && !isASCII(p) {
+			literal = false
+		}
+	}
+	if literal {
+		return newLiteralMatcher(patterns, options), nil
+	}
+
+	alternatives := make([]string, len(patterns))
+	for i, p := range patterns {
+		switch {
+		case options.fixed:
+			// Case-insensitive non-ASCII literals: lower-casing could change
+			// byte offsets, so they go through the regexp engine quoted.
+			p = regexp.QuoteMeta(p)
+		case options.basic:
+			p = translateBasic(p)
+		case options.extended:
+			p = translateExtended(p)
+		}
+		alternatives[i] = "(?:" + p + ")"
+	}
+	pattern := strings.Join(alternatives, "|")
+	if options.lineMatch {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	if options.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if options.basic || options.extended {
+		// POSIX semantics: of the matches starting leftmost, the longest wins.
+		re.Longest()
+	}
+	return &regexpMatcher{re: re, word: options.wordMatch}, nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// translateExtended rewrites a POSIX extended regular expression into RE2
+// syntax: the GNU word boundary operators \< and \> become \b, and bracket
+// expressions are rewritten by translateBracket.
+func translateExtended(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern) && (pattern[i+1] == '<' || pattern[i+1] == '>'):
+			b.WriteString(`\b`)
+			i++
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			b.WriteByte(pattern[i+1])
+			i++
+		case c == '[':
+			end := bracketEnd(pattern, i)
+			b.WriteString(translateBracket(pattern[i:end]))
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// translateBasic rewrites a POSIX basic regular expression into RE2 syntax:
+// \( \) \{ \} \| \+ \? become operators, their unescaped forms become
+// literals, a leading '*' is literal and \< \> become \b. Bracket
+// expressions are rewritten by translateBracket.
+func translateBasic(pattern string) string {
+	var b strings.Builder
+	atStart := true // position where '*' is a literal
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			next := pattern[i]
+			if strings.IndexByte("(){}|+?", next) >= 0 {
+				b.WriteByte(next)
+				atStart = next == '(' || next == '|'
+				continue
+			}
+			if next == '<' || next == '>' {
+				b.WriteString(`\b`)
+				break
+			}
+			b.WriteByte('\\')
+			b.WriteByte(next)
+		case strings.IndexByte("(){}|+?", c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '*' && atStart:
+			b.WriteString(`\*`)
+		case c == '[':
+			end := bracketEnd(pattern, i)
+			b.WriteString(translateBracket(pattern[i:end]))
+			i = end - 1
+		case c == '^' && atStart:
+			b.WriteByte(c)
+			continue
+		default:
+			b.WriteByte(c)
+		}
+		atStart = false
+	}
+	return b.String()
+}
+
+// translateBracket rewrites a POSIX bracket expression for RE2. A backslash
+// is an ordinary character inside POSIX brackets, so "[\]" matches a
+// backslash, while RE2 reads it as an escape.
+func translateBracket(expr string) string {
+	return strings.ReplaceAll(expr, `\`, `\\`)
+}
+
+// bracketEnd returns the index just past the bracket expression starting at
+// pattern[start], or len(pattern) when it is not terminated.
+func bracketEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for i < len(pattern) {
+		switch {
+		case pattern[i] == '[' && i+1 < len(pattern) && strings.IndexByte(":.=", pattern[i+1]) >= 0:
+			// Character class such as [:alpha:], copied up to its closing ":]".
+			if end := strings.Index(pattern[i+2:], string(pattern[i+1])+"]"); end >= 0 {
+				i += end + 4
+				continue
+			}
+		case pattern[i] == ']':
+			return i + 1
+		}
+		i++
+	}
+	return len(pattern)
+}
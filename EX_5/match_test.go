@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTranslateBasic(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{`a\(b\)*`, `a(b)*`},
+		{`a(b)`, `a\(b\)`},
+		{`x\{2,3\}`, `x{2,3}`},
+		{`x{2}`, `x\{2\}`},
+		{`a\|b`, `a|b`},
+		{`a+?`, `a\+\?`},
+		{`*a`, `\*a`},
+		{`^*a`, `^\*a`},
+		{`\(*a\)`, `(\*a)`},
+		{`\<foo\>`, `\bfoo\b`},
+		{`[\]`, `[\\]`},
+		{`[]a]`, `[]a]`},
+		{`[^]a]`, `[^]a]`},
+		{`[[:alpha:]]+`, `[[:alpha:]]\+`},
+		{`a\.b`, `a\.b`},
+	}
+
+	for _, test := range tests {
+		if result := translateBasic(test.pattern); result != test.expected {
+			t.Errorf("expected %q for %q, but got %q", test.expected, test.pattern, result)
+		}
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		options  GrepOptions
+		line     string
+		expected [][]int
+	}{
+		{[]string{"foo"}, GrepOptions{}, "foo bar foo", [][]int{{0, 3}, {8, 11}}},
+		{[]string{"foo", "bar"}, GrepOptions{}, "foo bar", [][]int{{0, 3}, {4, 7}}},
+		{[]string{"ab", "abc", "bcd"}, GrepOptions{}, "abcd", [][]int{{0, 3}}},
+		{[]string{"FOO"}, GrepOptions{ignoreCase: true}, "xfoo", [][]int{{1, 4}}},
+		{[]string{"ÉTÉ"}, GrepOptions{ignoreCase: true}, "été", [][]int{{0, 5}}},
+		{[]string{"a.c"}, GrepOptions{fixed: true}, "abc a.c", [][]int{{4, 7}}},
+		{[]string{"foo"}, GrepOptions{wordMatch: true}, "xfoo foo_ foo", [][]int{{10, 13}}},
+		{[]string{"foo"}, GrepOptions{lineMatch: true}, "foo", [][]int{{0, 3}}},
+		{[]string{"foo"}, GrepOptions{lineMatch: true}, "foo ", nil},
+		{[]string{"fo+"}, GrepOptions{extended: true, ignoreCase: true}, "FOOO", [][]int{{0, 4}}},
+		{[]string{`\<fo+\>`}, GrepOptions{extended: true}, "foo xfoo", [][]int{{0, 3}}},
+		{[]string{"a|ab"}, GrepOptions{extended: true}, "ab", [][]int{{0, 2}}},
+		{[]string{`a\|ab`}, GrepOptions{basic: true}, "ab", [][]int{{0, 2}}},
+		{[]string{`\(ab\)\{2\}`}, GrepOptions{basic: true, ignoreCase: true}, "xABab", [][]int{{1, 5}}},
+		{[]string{`[\]`}, GrepOptions{basic: true}, `a\b`, [][]int{{1, 2}}},
+		{[]string{`[\d]`}, GrepOptions{extended: true}, `1d`, [][]int{{1, 2}}},
+		{[]string{"b+", "x"}, GrepOptions{wordMatch: true}, "abb bb", [][]int{{4, 6}}},
+		{[]string{"o", "fo"}, GrepOptions{lineMatch: true}, "fo", [][]int{{0, 2}}},
+		{nil, GrepOptions{}, "anything", nil},
+	}
+
+	for _, test := range tests {
+		m, err := newMatcher(test.patterns, test.options)
+		if err != nil {
+			t.Errorf("%q %+v: %v", test.patterns, test.options, err)
+			continue
+		}
+		result := m.findAll(test.line)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%q %+v: expected %v in %q, but got %v", test.patterns, test.options, test.expected, test.line, result)
+		}
+		if m.match(test.line) != (len(test.expected) > 0) {
+			t.Errorf("%q %+v: expected match=%v for %q", test.patterns, test.options, len(test.expected) > 0, test.line)
+		}
+	}
+
+	if _, err := newMatcher([]string{"a("}, GrepOptions{extended: true}); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
+
+// naiveFindAll finds the leftmost-longest non-overlapping occurrences of the
+// patterns by trying every pattern at every position.
+func naiveFindAll(line string, patterns []string, fold, word bool) [][]int {
+	if fold {
+		line = strings.ToLower(line)
+	}
+	var result [][]int
+	for start := 0; start < len(line); {
+		end := -1
+		for _, p := range patterns {
+			if fold {
+				p = strings.ToLower(p)
+			}
+			if p != "" && strings.HasPrefix(line[start:], p) && start+len(p) > end &&
+				(!word || wordBounded(line, start, start+len(p))) {
+				end = start + len(p)
+			}
+		}
+		if end < 0 {
+			start++
+			continue
+		}
+		result = append(result, []int{start, end})
+		start = end
+	}
+	return result
+}
+
+func TestLiteralMatcherAgainstNaiveSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomString := func(alphabet string, n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 300; i++ {
+		patterns := make([]string, 1+rng.Intn(6))
+		for j := range patterns {
+			patterns[j] = randomString("abAB", 1+rng.Intn(4))
+		}
+		line := randomString("abAB _", rng.Intn(40))
+		for _, options := range []GrepOptions{{}, {ignoreCase: true}, {wordMatch: true}, {fixed: true, ignoreCase: true, wordMatch: true}} {
+			m, err := newMatcher(patterns, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := naiveFindAll(line, patterns, options.ignoreCase, options.wordMatch)
+			if result := m.findAll(line); !reflect.DeepEqual(result, expected) {
+				t.Fatalf("%q %+v: expected %v in %q, but got %v", patterns, options, expected, line, result)
+			}
+		}
+	}
+}
+
+func TestOnlyMatchingOutput(t *testing.T) {
+	input := "she sells sea shells\nno match here\nhe said she\n"
+	patterns := []string{"she", "he", "sea shell", "s"}
+	options := GrepOptions{onlyMatching: true, lineNum: true, byteOffset: true, maxCount: -1}
+
+	m, err := newMatcher(patterns, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p := newPrinter(&out, options)
+	if _, err := search(strings.NewReader(input), "in", p, m, options); err != nil {
+		t.Fatal(err)
+	}
+	p.flush()
+
+	var expected strings.Builder
+	offset := 0
+	for i, line := range strings.SplitAfter(input, "\n") {
+		for _, loc := range naiveFindAll(strings.TrimSuffix(line, "\n"), patterns, false, false) {
+			expected.WriteString(strings.Join([]string{strconv.Itoa(i + 1), strconv.Itoa(offset + loc[0]), line[loc[0]:loc[1]]}, ":") + "\n")
+		}
+		offset += len(line)
+	}
+	if out.String() != expected.String() {
+		t.Errorf("expected output\n%s\nbut got\n%s", expected.String(), out.String())
+	}
+}
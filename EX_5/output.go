@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// Separators between the prefix fields and the line, as in GNU grep.
+const (
+	matchSep   = ':'
+	contextSep = '-'
+)
+
+// printer writes results for all searched files. It is shared between files
+// so that group separators also appear between groups of different files.
+type printer struct {
+	w       *bufio.Writer
+	options GrepOptions
+	colors  *colors // nil when output is not highlighted
+	printed bool    // a group was printed, the next one needs a separator
+
+	// firstGroup, when set, decides on the separator before the first group
+	// instead: the groups printed before it are not known to this printer
+	// when it prints one file of a parallel search.
+	firstGroup func()
+
+	// --json state: whether the current file's "begin" record is written,
+	// where it turned out to be binary and the statistics for the current
+	// file and for all files printed so far.
+	begun        bool
+	binaryOffset *int64
+	fileStats    jsonStats
+	stats        jsonStats
+}
+
+func newPrinter(w io.Writer, options GrepOptions) *printer {
+	return &printer{w: bufio.NewWriter(w), options: options, colors: options.colors}
+}
+
+// paint highlights s with the color selected by pick when colors are on.
+func (p *printer) paint(s string, pick func(*colors) string) string {
+	if p.colors == nil {
+		return s
+	}
+	return paint(s, pick(p.colors))
+}
+
+// startGroup begins a group of lines that is not adjacent to the previously
+// printed one. With context enabled, groups are separated by "--" or the
+// --group-separator string.
+func (p *printer) startGroup() {
+	if !p.printed && p.firstGroup != nil {
+		p.firstGroup()
+	} else if p.printed && !p.options.json && p.options.separate && (p.options.before > 0 || p.options.after > 0) {
+		p.w.WriteString(p.paint(p.options.groupSeparator, func(c *colors) string { return c.separator }))
+		p.w.WriteByte('\n')
+	}
+	p.printed = true
+}
+
+// prefix writes the file name, line number and byte offset enabled by the
+// options, each followed by sep.
+func (p *printer) prefix(name string, num int, offset int64, sep byte) {
+	s := p.paint(string(sep), func(c *colors) string { return c.separator })
+	if p.options.withName {
+		p.w.WriteString(p.paint(name, func(c *colors) string { return c.fileName }))
+		p.w.WriteString(s)
+	}
+	if p.options.lineNum {
+		p.w.WriteString(p.paint(strconv.Itoa(num), func(c *colors) string { return c.lineNum }))
+		p.w.WriteString(s)
+	}
+	if p.options.byteOffset {
+		p.w.WriteString(p.paint(strconv.FormatInt(offset, 10), func(c *colors) string { return c.byteOffset }))
+		p.w.WriteString(s)
+	}
+}
+
+// line prints a selected line with sep ':' or a context line with sep '-'.
+// With colors on, the matches of m are highlighted in the lines that
+// contain them: selected lines normally, context lines with -v.
+func (p *printer) line(name string, l contextLine, sep byte, m matcher) {
+	if p.options.json {
+		p.jsonLine(name, l, sep, m)
+		return
+	}
+	p.prefix(name, l.num, l.offset, sep)
+	if p.colors == nil || (sep == matchSep) == p.options.invert {
+		p.w.WriteString(l.text)
+		p.w.WriteByte('\n')
+		return
+	}
+
+	sgr := p.colors.selectedMatch
+	if sep == contextSep {
+		sgr = p.colors.contextMatch
+	}
+	pos := 0
+	for _, loc := range m.findAll(l.text) {
+		p.w.WriteString(l.text[pos:loc[0]])
+		p.w.WriteString(paint(l.text[loc[0]:loc[1]], sgr))
+		pos = loc[1]
+	}
+	p.w.WriteString(l.text[pos:])
+	p.w.WriteByte('\n')
+}
+
+// onlyMatching prints every match of m in a selected line on its own line
+// (-o). With -b the offset is that of the match rather than of the line.
+func (p *printer) onlyMatching(name string, l contextLine, m matcher) {
+	for _, loc := range m.findAll(l.text) {
+		p.prefix(name, l.num, l.offset+int64(loc[0]), matchSep)
+		p.w.WriteString(p.paint(l.text[loc[0]:loc[1]], func(c *colors) string { return c.selectedMatch }))
+		p.w.WriteByte('\n')
+	}
+}
+
+// count prints the number of matching lines of a file.
+func (p *printer) count(name string, n int) {
+	if p.options.withName {
+		p.w.WriteString(p.paint(name, func(c *colors) string { return c.fileName }))
+		p.w.WriteString(p.paint(string(matchSep), func(c *colors) string { return c.separator }))
+	}
+	p.w.WriteString(strconv.Itoa(n))
+	p.w.WriteByte('\n')
+}
+
+// fileName prints the name of a file that matches (-l) or does not (-L).
+func (p *printer) fileName(name string) {
+	p.w.WriteString(p.paint(name, func(c *colors) string { return c.fileName }))
+	p.w.WriteByte('\n')
+}
+
+// binaryMatch notes that a binary file matches instead of printing its lines.
+// offset is that of the NUL byte that gave it away, reported with --json.
+func (p *printer) binaryMatch(name string, offset int64) {
+	if p.options.json {
+		p.beginFile(name)
+		p.binaryOffset = &offset
+		return
+	}
+	p.w.WriteString("Binary file " + name + " matches\n")
+}
+
+func (p *printer) flush() error {
+	return p.w.Flush()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxAheadPerWorker bounds how many files past the one being printed may be
+// searched and held in memory, per worker.
+const maxAheadPerWorker = 4
+
+// fileOutput collects the output of one file searched in parallel. Until it
+// is the file's turn to be printed the output is buffered; from then on it
+// goes straight to the shared printer, so a file at the head of the order,
+// such as a slow standard input, streams like in a sequential search.
+type fileOutput struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	p        *printer // the shared printer once it is this file's turn
+	grouped  bool     // the buffered output starts with a group of lines
+	path     string
+	walkErr  error
+	err      error
+	matched  bool
+	stats    jsonStats
+	finished chan struct{}
+}
+
+func (o *fileOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.p == nil {
+		return o.buf.Write(b)
+	}
+	o.p.w.Write(b)
+	return len(b), o.p.flush()
+}
+
+// firstGroup is called when the file's printer starts its first group of
+// lines. Whether it needs a separator depends on the files before it, which
+// are only known to be done once it is this file's turn.
+func (o *fileOutput) firstGroup() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.p == nil {
+		o.grouped = true
+	} else {
+		o.p.startGroup()
+	}
+}
+
+// stream makes it the file's turn: the output buffered so far is copied to p,
+// and later output is written to p directly.
+func (o *fileOutput) stream(p *printer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.grouped {
+		p.startGroup()
+	}
+	p.w.Write(o.buf.Bytes())
+	p.flush()
+	o.buf = bytes.Buffer{}
+	o.p = p
+}
+
+// grepParallel searches the files with a pool of workers, one per CPU. The
+// file whose turn it is to be printed writes to p directly; files after it
+// are searched ahead into buffers, at most a few per worker, and copied to p
+// in the order walkInputs produced them, so the output is the same as a
+// sequential search. It reports whether any line was selected and whether
+// any file failed. With -q the search stops at the first match.
+func grepParallel(files []string, p *printer, m matcher, options GrepOptions) (matched, failed bool) {
+	workers := runtime.NumCPU()
+	jobs := make(chan *fileOutput)
+	order := make(chan *fileOutput, workers*maxAheadPerWorker)
+	ahead := make(chan struct{}, workers*maxAheadPerWorker)
+
+	var found atomic.Bool // a line was selected in some file
+	for i := 0; i < workers; i++ {
+		go func() {
+			for o := range jobs {
+				if o.walkErr == nil && !(options.quiet && found.Load()) {
+					fp := newPrinter(o, options)
+					fp.firstGroup = o.firstGroup
+					o.matched, o.err = grepFile(o.path, fp, m, options)
+					fp.flush()
+					o.stats = fp.stats
+					if o.matched {
+						found.Store(true)
+					}
+				}
+				close(o.finished)
+			}
+		}()
+	}
+	go func() {
+		walkInputs(files, options, func(path string, err error) bool {
+			ahead <- struct{}{}
+			o := &fileOutput{path: path, walkErr: err, finished: make(chan struct{})}
+			order <- o
+			jobs <- o
+			return !(options.quiet && found.Load())
+		})
+		close(jobs)
+		close(order)
+	}()
+
+	for o := range order {
+		o.stream(p)
+		<-o.finished
+		<-ahead
+		p.stats.add(o.stats)
+		matched = matched || o.matched
+		err := o.walkErr
+		if err == nil {
+			err = o.err
+		}
+		failed = reportError(o.path, err, options) || failed
+	}
+	return matched, failed
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrepParallelOrder(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 60; i++ {
+		var content strings.Builder
+		// Files of very different sizes finish in a different order than
+		// they are given.
+		for j := 0; j < (i%7)*500+3; j++ {
+			fmt.Fprintf(&content, "file %d line %d\n", i, j)
+		}
+		name := filepath.Join(dir, fmt.Sprintf("f%02d", i))
+		if err := os.WriteFile(name, []byte(content.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, name)
+	}
+	files = append(files, filepath.Join(dir, "missing"))
+
+	tests := []GrepOptions{
+		{},
+		{after: 1, before: 1, separate: true, groupSeparator: "--"},
+		{count: true},
+		{listMatching: true},
+		{listNonMatching: true},
+		{maxCount: 1, lineNum: true},
+	}
+
+	for _, options := range tests {
+		options.withName = true
+		options.suppressErrors = true
+		if options.maxCount == 0 {
+			options.maxCount = -1
+		}
+		m, err := newMatcher([]string{"line 1$", "line 2$", "file 3 "}, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected bytes.Buffer
+		p := newPrinter(&expected, options)
+		var expectedMatched bool
+		for _, name := range files {
+			found, _ := grepFile(name, p, m, options)
+			expectedMatched = expectedMatched || found
+		}
+		p.flush()
+
+		var out bytes.Buffer
+		p = newPrinter(&out, options)
+		matched, failed := grepParallel(files, p, m, options)
+		p.flush()
+
+		if out.String() != expected.String() {
+			t.Errorf("%+v: parallel output differs from a sequential search:\n%s\nexpected:\n%s", options, out.String(), expected.String())
+		}
+		if matched != expectedMatched || !failed {
+			t.Errorf("%+v: expected matched=%v and failed=true, but got %v and %v", options, expectedMatched, matched, failed)
+		}
+	}
+}
+
+func TestGrepParallelStreamsFirstInput(t *testing.T) {
+	other := filepath.Join(t.TempDir(), "other")
+	if err := os.WriteFile(other, []byte("foo other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	options := GrepOptions{maxCount: -1, withName: true}
+	m, _ := newMatcher([]string{"foo"}, options)
+	var out syncBuffer
+	p := newPrinter(&out, options)
+	done := make(chan struct{})
+	go func() {
+		grepParallel([]string{"-", other}, p, m, options)
+		p.flush()
+		close(done)
+	}()
+
+	// Standard input is first in order, so its matches are printed while it
+	// is still open, like tail -f log | grep foo - other.
+	w.WriteString("foo 1\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for out.String() != "(standard input):foo 1\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the first match before standard input ends, but got %q", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Close()
+	<-done
+	if expected := "(standard input):foo 1\n" + other + ":foo other\n"; out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// patternList is the repeatable -e flag collecting patterns.
+type patternList []string
+
+func (l *patternList) String() string { return fmt.Sprint(*l) }
+
+func (l *patternList) Set(pattern string) error {
+	*l = append(*l, splitPatterns(pattern)...)
+	return nil
+}
+
+// patternFiles is the repeatable -f flag: every line of each file is a
+// pattern ("-" reads standard input).
+type patternFiles []string
+
+func (f *patternFiles) String() string { return fmt.Sprint(*f) }
+
+func (f *patternFiles) Set(path string) error {
+	*f = append(*f, path)
+	return nil
+}
+
+// read returns the patterns in the files, one per line.
+func (f patternFiles) read() ([]string, error) {
+	var patterns []string
+	for _, path := range f {
+		in := os.Stdin
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			in = file
+		}
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 1<<30)
+		for scanner.Scan() {
+			patterns = append(patterns, strings.TrimSuffix(scanner.Text(), "\r"))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return patterns, nil
+}
+
+// splitPatterns splits a pattern argument at newlines: like GNU grep, each
+// line is a pattern of its own.
+func splitPatterns(pattern string) []string {
+	return strings.Split(pattern, "\n")
+}
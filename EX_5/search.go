@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// contextLine is a line kept for printing as leading context.
+type contextLine struct {
+	num    int
+	offset int64 // byte offset of the line in the input
+	text   string
+	eol    string // the line terminator read, "" for a last line without one
+}
+
+// lineRing holds the last few lines read for -B context. It grows as lines
+// arrive up to its capacity and never beyond, however long the input is, so
+// a huge -B only takes memory for the lines actually held.
+type lineRing struct {
+	lines    []contextLine
+	capacity int
+	start    int
+	size     int
+}
+
+func newLineRing(capacity int) *lineRing {
+	return &lineRing{capacity: capacity}
+}
+
+func (r *lineRing) push(l contextLine) {
+	if r.capacity == 0 {
+		return
+	}
+	if r.size < len(r.lines) {
+		r.lines[(r.start+r.size)%len(r.lines)] = l
+		r.size++
+		return
+	}
+	if len(r.lines) < r.capacity {
+		// The oldest line only moves off index 0 once the ring is at
+		// capacity, so until then it grows by appending.
+		r.lines = append(r.lines, l)
+		r.size++
+		return
+	}
+	r.lines[r.start] = l
+	r.start = (r.start + 1) % len(r.lines)
+}
+
+// drain calls f for the buffered lines from oldest to newest and empties
+// the ring.
+func (r *lineRing) drain(f func(contextLine)) {
+	for i := 0; i < r.size; i++ {
+		f(r.lines[(r.start+i)%len(r.lines)])
+	}
+	r.start, r.size = 0, 0
+}
+
+// binaryCheckSize is the most of the input's first read that is inspected for
+// NUL bytes up front to decide whether it is binary.
+const binaryCheckSize = 8 * 1024
+
+// search streams r line by line and prints matching lines with their
+// context through p, prefixed with name when options.withName is set. Memory use is
+// bounded by the -B context and the longest line, so arbitrarily large files
+// and endless streams such as tail -f work. For binary input only a note
+// that it matches is printed. Reading stops early once the result is known:
+// after -m NUM selected lines and their trailing context, or at the first
+// selected line with -l, -L and -q. It reports whether any line was
+// selected.
+func search(r io.Reader, name string, p *printer, m matcher, options GrepOptions) (bool, error) {
+	listing := options.listMatching || options.listNonMatching
+	silent := options.count || listing || options.quiet // no lines are printed
+	limit := options.maxCount
+	if listing || options.quiet {
+		limit = 1
+	}
+
+	count := 0
+	var offset int64
+	finish := func() (bool, error) {
+		switch {
+		case options.quiet:
+		case options.listMatching && count > 0, options.listNonMatching && count == 0:
+			p.fileName(name)
+		case options.count && !listing:
+			p.count(name, count)
+		}
+		p.endFile(name, count, offset)
+		return count > 0, nil
+	}
+	if limit == 0 {
+		return finish()
+	}
+
+	in := bufio.NewReaderSize(r, 64*1024)
+
+	// Only what the first read returned is checked up front, so a slow
+	// stream is not held up waiting for more input. NUL bytes further on are
+	// found line by line.
+	in.Peek(1)
+	head, _ := in.Peek(min(in.Buffered(), binaryCheckSize))
+	nul := int64(bytes.IndexByte(head, 0)) // offset of the first NUL byte, -1 if none
+
+	before := newLineRing(options.before)
+	afterLeft := 0
+	limitReached := false
+	last := 0 // number of the last printed line, 0 before the first
+
+	printLine := func(sep byte) func(contextLine) {
+		return func(l contextLine) {
+			if last == 0 || l.num != last+1 {
+				p.startGroup()
+			}
+			p.line(name, l, sep, m)
+			last = l.num
+		}
+	}
+	printMatch, printContext := printLine(matchSep), printLine(contextSep)
+
+	for num := 1; ; num++ {
+		text, err := in.ReadString('\n')
+		if len(text) == 0 && err != nil {
+			if err != io.EOF {
+				return count > 0, err
+			}
+			return finish()
+		}
+		if i := strings.IndexByte(text, 0); i >= 0 && nul < 0 {
+			nul = offset + int64(i)
+		}
+		line := contextLine{num: num, offset: offset, text: strings.TrimSuffix(text, "\n")}
+		line.eol = text[len(line.text):]
+		offset += int64(len(text))
+		text = line.text
+
+		switch {
+		case limitReached:
+			// Past the last selected line only trailing context is printed,
+			// even for lines that match.
+			afterLeft--
+			printContext(line)
+		case options.invert != m.match(text):
+			count++
+			limitReached = count == limit
+			if nul >= 0 && !silent {
+				p.binaryMatch(name, nul)
+				return finish()
+			}
+			if options.onlyMatching && !silent {
+				if !options.invert {
+					p.onlyMatching(name, line, m)
+				}
+			} else if !silent {
+				before.drain(printContext)
+				printMatch(line)
+				afterLeft = options.after
+			}
+		case afterLeft > 0:
+			afterLeft--
+			printContext(line)
+		default:
+			before.push(line)
+		}
+
+		if limitReached && afterLeft == 0 {
+			return finish()
+		}
+
+		// Flush when no more input is ready, so that output from a slow
+		// stream appears without waiting for the buffer to fill.
+		if in.Buffered() == 0 {
+			if err := p.flush(); err != nil {
+				return count > 0, err
+			}
+		}
+	}
+}
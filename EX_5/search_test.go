@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLineRing(t *testing.T) {
+	tests := []struct {
+		capacity int
+		pushed   int
+		expected []int
+	}{
+		{0, 5, nil},
+		{3, 2, []int{1, 2}},
+		{3, 3, []int{1, 2, 3}},
+		{3, 7, []int{5, 6, 7}},
+		{1000000000, 4, []int{1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		r := newLineRing(test.capacity)
+		for i := 1; i <= test.pushed; i++ {
+			r.push(contextLine{num: i})
+		}
+		var result []int
+		r.drain(func(l contextLine) { result = append(result, l.num) })
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("capacity %d, %d pushed: expected %v, but got %v", test.capacity, test.pushed, test.expected, result)
+		}
+		r.drain(func(l contextLine) { t.Errorf("capacity %d: expected an empty ring after drain", test.capacity) })
+		if len(r.lines) > test.pushed {
+			t.Errorf("capacity %d, %d pushed: expected at most %d slots, but got %d", test.capacity, test.pushed, test.pushed, len(r.lines))
+		}
+	}
+
+	// Lines pushed after a drain reuse the slots and keep their order.
+	r := newLineRing(3)
+	for i := 1; i <= 5; i++ {
+		r.push(contextLine{num: i})
+	}
+	r.drain(func(contextLine) {})
+	for i := 6; i <= 7; i++ {
+		r.push(contextLine{num: i})
+	}
+	var result []int
+	r.drain(func(l contextLine) { result = append(result, l.num) })
+	if !reflect.DeepEqual(result, []int{6, 7}) {
+		t.Errorf("expected [6 7] after a drain, but got %v", result)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while search writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSearchStreams(t *testing.T) {
+	options := GrepOptions{maxCount: -1}
+	m, _ := newMatcher([]string{"foo"}, options)
+	var out syncBuffer
+	p := newPrinter(&out, options)
+
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := search(r, "-", p, m, options)
+		done <- err
+	}()
+
+	// A match is printed as soon as its line arrives, without waiting for
+	// more input or the end of the stream.
+	io.WriteString(w, "foo 1\nbar\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for out.String() != "foo 1\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the first match before the stream ends, but got %q", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	io.WriteString(w, "foo 2\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "foo 1\nfoo 2\n" {
+		t.Errorf("expected both matches, but got %q", out.String())
+	}
+}
+
+func TestSearchLongInput(t *testing.T) {
+	// Far more input than the -B ring and the read buffer hold.
+	var in strings.Builder
+	for i := 0; i < 100000; i++ {
+		in.WriteString("filler line\n")
+	}
+	in.WriteString("a\nb\nmatch\nz\n")
+
+	options := GrepOptions{before: 2, maxCount: -1}
+	m, _ := newMatcher([]string{"match"}, options)
+	var out bytes.Buffer
+	p := newPrinter(&out, options)
+	if _, err := search(strings.NewReader(in.String()), "-", p, m, options); err != nil {
+		t.Fatal(err)
+	}
+	p.flush()
+	if out.String() != "a\nb\nmatch\n" {
+		t.Errorf("expected two lines of leading context, but got %q", out.String())
+	}
+}
+
+func TestContextLines(t *testing.T) {
+	tests := []struct {
+		after, before, context int
+		explicit               []string
+		expectedAfter          int
+		expectedBefore         int
+	}{
+		{0, 0, 0, nil, 0, 0},
+		{0, 0, 2, []string{"C"}, 2, 2},
+		{1, 0, 3, []string{"A", "C"}, 1, 3},
+		{0, 0, 3, []string{"B", "C"}, 3, 0},
+		{4, 1, 0, []string{"A", "B"}, 4, 1},
+	}
+
+	for _, test := range tests {
+		explicit := map[string]bool{}
+		for _, name := range test.explicit {
+			explicit[name] = true
+		}
+		after, before, err := contextLines(test.after, test.before, test.context, explicit)
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+		}
+		if after != test.expectedAfter || before != test.expectedBefore {
+			t.Errorf("%+v: expected -A %d -B %d, but got -A %d -B %d",
+				test, test.expectedAfter, test.expectedBefore, after, before)
+		}
+	}
+}
+
+func TestContextLinesNegative(t *testing.T) {
+	tests := []struct {
+		after, before, context int
+		explicit               []string
+	}{
+		{-1, 0, 0, []string{"A"}},
+		{0, -1, 0, []string{"B"}},
+		{0, 0, -2, []string{"C"}},
+		{1, 1, -2, []string{"A", "B", "C"}},
+	}
+
+	for _, test := range tests {
+		explicit := map[string]bool{}
+		for _, name := range test.explicit {
+			explicit[name] = true
+		}
+		_, _, err := contextLines(test.after, test.before, test.context, explicit)
+		if err == nil || !strings.Contains(err.Error(), "invalid context length argument") {
+			t.Errorf("%+v: expected an invalid context length error, but got %v", test, err)
+		}
+	}
+}
+
+func TestSearchContextSeparators(t *testing.T) {
+	input := strings.ReplaceAll("1 2 3 x4 5 6 7 8 x9 10 11 12 13 x14 x15 16 ", " ", "\n")
+
+	tests := []struct {
+		options  GrepOptions
+		expected string
+	}{
+		{GrepOptions{}, "x4\nx9\nx14\nx15\n"},
+		{GrepOptions{after: 1, before: 1, separate: true, groupSeparator: "--"},
+			"3\nx4\n5\n--\n8\nx9\n10\n--\n13\nx14\nx15\n16\n"},
+		// Groups that touch are merged, not separated.
+		{GrepOptions{after: 2, before: 2, separate: true, groupSeparator: "--"},
+			"2\n3\nx4\n5\n6\n7\n8\nx9\n10\n11\n12\n13\nx14\nx15\n16\n"},
+		{GrepOptions{after: 1, separate: true, groupSeparator: "=="},
+			"x4\n5\n==\nx9\n10\n==\nx14\nx15\n16\n"},
+		{GrepOptions{before: 1, lineNum: true, separate: true, groupSeparator: "--"},
+			"3-3\n4:x4\n--\n8-8\n9:x9\n--\n13-13\n14:x14\n15:x15\n"},
+		{GrepOptions{after: 1, before: 1, lineNum: true, withName: true, byteOffset: true},
+			"f-3-4-3\nf:4:6:x4\nf-5-9-5\n" +
+				"f-8-15-8\nf:9:17:x9\nf-10-20-10\n" +
+				"f-13-29-13\nf:14:32:x14\nf:15:36:x15\nf-16-40-16\n"},
+		{GrepOptions{after: 1, invert: true, separate: true, groupSeparator: "--", maxCount: 2}, "1\n2\n3\n"},
+		{GrepOptions{before: 1, separate: true, groupSeparator: "--", maxCount: 2}, "3\nx4\n--\n8\nx9\n"},
+	}
+
+	for _, test := range tests {
+		if test.options.maxCount == 0 {
+			test.options.maxCount = -1
+		}
+		m, err := newMatcher([]string{"x"}, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		p := newPrinter(&out, test.options)
+		if _, err := search(strings.NewReader(input), "f", p, m, test.options); err != nil {
+			t.Fatal(err)
+		}
+		p.flush()
+		if out.String() != test.expected {
+			t.Errorf("%+v: expected\n%s\nbut got\n%s", test.options, test.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// globList is a repeatable flag collecting glob patterns.
+type globList []string
+
+func (g *globList) String() string { return fmt.Sprint(*g) }
+
+func (g *globList) Set(pattern string) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	*g = append(*g, pattern)
+	return nil
+}
+
+// matches reports whether the base name of path matches one of the globs.
+func (g globList) matches(path string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range g {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// selected reports whether a file passes the --include and --exclude filters.
+func (options GrepOptions) selected(path string) bool {
+	if len(options.include) > 0 && !options.include.matches(path) {
+		return false
+	}
+	return !options.exclude.matches(path)
+}
+
+// defaultInputs returns the inputs searched when no file is named: the
+// working directory with -r, standard input otherwise.
+func defaultInputs(recursive bool) []string {
+	if recursive {
+		return []string{"."}
+	}
+	return []string{"-"}
+}
+
+// walkInputs calls visit for every file to search, in command line order.
+// "-" stands for standard input. With -r directories are searched
+// recursively in lexical order, otherwise a directory is reported as an
+// error. Errors for single files are passed to visit instead of stopping
+// the walk. The walk stops when visit returns false.
+func walkInputs(paths []string, options GrepOptions, visit func(path string, err error) bool) {
+	for _, path := range paths {
+		if path == "-" || !options.recursive {
+			if (path == "-" || options.selected(path)) && !visit(path, nil) {
+				return
+			}
+			continue
+		}
+		stopped := false
+		filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				stopped = !visit(p, err)
+			case d.IsDir():
+				if p != path && options.excludeDir.matches(p) {
+					return filepath.SkipDir
+				}
+			case d.Type().IsRegular() || p == path:
+				if options.selected(p) {
+					stopped = !visit(p, nil)
+				}
+			}
+			if stopped {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if stopped {
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestGlobList(t *testing.T) {
+	var g globList
+	if err := g.Set("[a-"); err == nil {
+		t.Errorf("expected an error for an invalid glob")
+	}
+	for _, pattern := range []string{"*.go", "Makefile"} {
+		if err := g.Set(pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", true},
+		{"dir/sub/walk.go", true},
+		{"Makefile", true},
+		{"dir/Makefile.old", false},
+		// Only the base name is matched.
+		{"x.go/readme", false},
+	}
+
+	for _, test := range tests {
+		if result := g.matches(test.path); result != test.expected {
+			t.Errorf("%q in %v: expected %v, but got %v", test.path, g, test.expected, result)
+		}
+	}
+}
+
+// walkTree creates files under a temporary directory and returns it.
+func walkTree(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("foo\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestWalkInputs(t *testing.T) {
+	dir := walkTree(t, "b.go", "a.txt", "c/z.go", "c/a.go", "vendor/v.go", "B/x.txt")
+
+	glob := func(patterns ...string) globList { return patterns }
+	tests := []struct {
+		paths    []string
+		options  GrepOptions
+		expected []string
+	}{
+		// Entries are visited in lexical order, upper case first.
+		{[]string{"."}, GrepOptions{recursive: true},
+			[]string{"B/x.txt", "a.txt", "b.go", "c/a.go", "c/z.go", "vendor/v.go"}},
+		{[]string{"."}, GrepOptions{recursive: true, include: glob("*.go")},
+			[]string{"b.go", "c/a.go", "c/z.go", "vendor/v.go"}},
+		{[]string{"."}, GrepOptions{recursive: true, exclude: glob("*.go")},
+			[]string{"B/x.txt", "a.txt"}},
+		{[]string{"."}, GrepOptions{recursive: true, include: glob("*.go"), exclude: glob("a*")},
+			[]string{"b.go", "c/z.go", "vendor/v.go"}},
+		{[]string{"."}, GrepOptions{recursive: true, excludeDir: glob("vendor", "B")},
+			[]string{"a.txt", "b.go", "c/a.go", "c/z.go"}},
+		// A directory named on the command line is searched even if excluded.
+		{[]string{"c"}, GrepOptions{recursive: true, excludeDir: glob("c")},
+			[]string{"c/a.go", "c/z.go"}},
+		// The command line order is kept, and "-" is passed through.
+		{[]string{"c", "-", "a.txt"}, GrepOptions{recursive: true},
+			[]string{"c/a.go", "c/z.go", "-", "a.txt"}},
+		{[]string{"b.go", "-", "a.txt"}, GrepOptions{include: glob("*.txt")},
+			[]string{"-", "a.txt"}},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, test := range tests {
+		var result []string
+		walkInputs(test.paths, test.options, func(path string, err error) bool {
+			if err != nil {
+				t.Errorf("%s: %v", path, err)
+			}
+			result = append(result, filepath.ToSlash(path))
+			return true
+		})
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%q %+v: expected %q, but got %q", test.paths, test.options, test.expected, result)
+		}
+	}
+
+	// Without -r a directory is visited as is and fails when read; the walk
+	// stops as soon as visit returns false.
+	var result []string
+	walkInputs([]string{"c", "a.txt", "b.go"}, GrepOptions{}, func(path string, err error) bool {
+		result = append(result, path)
+		return path != "a.txt"
+	})
+	if !reflect.DeepEqual(result, []string{"c", "a.txt"}) {
+		t.Errorf("expected [c a.txt], but got %q", result)
+	}
+}
+
+func TestDefaultInputs(t *testing.T) {
+	if result := defaultInputs(true); !reflect.DeepEqual(result, []string{"."}) {
+		t.Errorf("expected [.] with -r, but got %q", result)
+	}
+	if result := defaultInputs(false); !reflect.DeepEqual(result, []string{"-"}) {
+		t.Errorf("expected [-] without -r, but got %q", result)
+	}
+}
+
+func TestGrepFileStdin(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(name, []byte("foo\nbar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	options := GrepOptions{withName: true, maxCount: -1}
+	m, _ := newMatcher([]string{"foo"}, options)
+	var out bytes.Buffer
+	p := newPrinter(&out, options)
+	if _, err := grepFile("-", p, m, options); err != nil {
+		t.Fatal(err)
+	}
+	p.flush()
+	if out.String() != "(standard input):foo\n" {
+		t.Errorf("expected the match from standard input, but got %q", out.String())
+	}
+}
+
+func TestSearchBinary(t *testing.T) {
+	// A NUL byte far past the first buffer of input.
+	long := strings.Repeat("filler line\n", 20000) + "x\x00y\nfoo\n"
+
+	tests := []struct {
+		input    string
+		slow     bool // the input arrives one byte per read
+		expected string
+	}{
+		{"foo\x00\n", false, "Binary file f matches\n"},
+		{"bar\n\x00\nfoo\n", false, "Binary file f matches\n"},
+		{"bar\n\x00\nfoo\n", true, "Binary file f matches\n"},
+		{long, false, "Binary file f matches\n"},
+		{"\x00\nbar\n", false, ""},
+		// Lines before the first NUL byte are printed as text.
+		{"foo\n\x00\nfoo\n", true, "foo\nBinary file f matches\n"},
+	}
+
+	for _, test := range tests {
+		options := GrepOptions{maxCount: -1}
+		m, _ := newMatcher([]string{"foo"}, options)
+		var out bytes.Buffer
+		p := newPrinter(&out, options)
+		r := strings.NewReader(test.input)
+		var err error
+		if test.slow {
+			_, err = search(iotest.OneByteReader(r), "f", p, m, options)
+		} else {
+			_, err = search(r, "f", p, m, options)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.flush()
+		if out.String() != test.expected {
+			t.Errorf("%.20q (slow %v): expected %q, but got %q", test.input, test.slow, test.expected, out.String())
+		}
+	}
+}